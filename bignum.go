@@ -0,0 +1,223 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// max absolute decimal exponent of numbers converted to *big.Int or *big.Rat,
+// it avoids huge memory and cpu cost of numbers like 1e1000000000
+const maxBigExp = 10000
+
+//// big numbers, they are exact only if unmarshalled with useNumber=true or by UnmarshalSmartNumbers,
+//// float64 values decoded with useNumber=false return def and an error of ErrPrecisionLoss
+
+func (d JsonMap) GetBigInt(key string, def *big.Int) (val *big.Int, found bool, err error) {
+	return getBig(d, []string{key}, def, ConvPolicy.toBigInt)
+}
+
+func (d JsonMap) RGetBigInt(keyPath []string, def *big.Int) (val *big.Int, found bool, err error) {
+	return getBig(d, keyPath, def, ConvPolicy.toBigInt)
+}
+
+func (d JsonMap) DGetBigInt(path string, def *big.Int) (val *big.Int, found bool, err error) {
+	return dGetBig(d, path, def, ConvPolicy.toBigInt)
+}
+
+// prec is the mantissa precision in bits, 0 means 64
+func (d JsonMap) GetBigFloat(key string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return getBig(d, []string{key}, def, bigFloatConv(prec))
+}
+
+func (d JsonMap) RGetBigFloat(keyPath []string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return getBig(d, keyPath, def, bigFloatConv(prec))
+}
+
+func (d JsonMap) DGetBigFloat(path string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return dGetBig(d, path, def, bigFloatConv(prec))
+}
+
+// exact decimal, e.g. "0.1" is 1/10
+func (d JsonMap) GetRat(key string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return getBig(d, []string{key}, def, ConvPolicy.toRat)
+}
+
+func (d JsonMap) RGetRat(keyPath []string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return getBig(d, keyPath, def, ConvPolicy.toRat)
+}
+
+func (d JsonMap) DGetRat(path string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return dGetBig(d, path, def, ConvPolicy.toRat)
+}
+
+//// big numbers of View and other types embedding getters
+
+func (g getters) GetBigInt(key string, def *big.Int) (val *big.Int, found bool, err error) {
+	return getBig(g.source(), []string{key}, def, ConvPolicy.toBigInt)
+}
+
+func (g getters) RGetBigInt(keyPath []string, def *big.Int) (val *big.Int, found bool, err error) {
+	return getBig(g.source(), keyPath, def, ConvPolicy.toBigInt)
+}
+
+func (g getters) DGetBigInt(path string, def *big.Int) (val *big.Int, found bool, err error) {
+	return dGetBig(g.source(), path, def, ConvPolicy.toBigInt)
+}
+
+func (g getters) GetBigFloat(key string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return getBig(g.source(), []string{key}, def, bigFloatConv(prec))
+}
+
+func (g getters) RGetBigFloat(keyPath []string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return getBig(g.source(), keyPath, def, bigFloatConv(prec))
+}
+
+func (g getters) DGetBigFloat(path string, prec uint, def *big.Float) (val *big.Float, found bool, err error) {
+	return dGetBig(g.source(), path, def, bigFloatConv(prec))
+}
+
+func (g getters) GetRat(key string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return getBig(g.source(), []string{key}, def, ConvPolicy.toRat)
+}
+
+func (g getters) RGetRat(keyPath []string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return getBig(g.source(), keyPath, def, ConvPolicy.toRat)
+}
+
+func (g getters) DGetRat(path string, def *big.Rat) (val *big.Rat, found bool, err error) {
+	return dGetBig(g.source(), path, def, ConvPolicy.toRat)
+}
+
+//// implementation
+
+func getBig[T any](d Getter, keyPath []string, def T, conv func(ConvPolicy, interface{}, T) (T, error)) (
+	val T, found bool, err error) {
+	if len(keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	raw, found, err := d.lookup(keyPath)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = conv(d.convPolicy(), raw, def)
+	return val, true, withPath(err, keyPath)
+}
+
+func dGetBig[T any](d Getter, path string, def T, conv func(ConvPolicy, interface{}, T) (T, error)) (
+	val T, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return getBig(d, keyPath, def, conv)
+}
+
+// exact text of a number, strings are accepted if Coerce is set
+func (p ConvPolicy) bigText(raw, def interface{}) (string, error) {
+	switch r := raw.(type) {
+	case json.Number:
+		return string(r), nil
+	case int64:
+		return strconv.FormatInt(r, 10), nil
+	case uint64:
+		return strconv.FormatUint(r, 10), nil
+	case string:
+		if p.Coerce && isNumberLiteral(r) {
+			return r, nil
+		}
+	case float64:
+		return "", precisionLossError(raw, def)
+	}
+	return "", typeError(raw, def)
+}
+
+func (p ConvPolicy) toRat(raw interface{}, def *big.Rat) (*big.Rat, error) {
+	s, err := p.bigText(raw, def)
+	if err != nil {
+		return def, err
+	}
+	if !expInRange(s) {
+		return def, overflowError(raw, def)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return def, typeError(raw, def)
+	}
+	return r, nil
+}
+
+// fractional part is handled by p.Fraction
+func (p ConvPolicy) toBigInt(raw interface{}, def *big.Int) (*big.Int, error) {
+	s, err := p.bigText(raw, def)
+	if err != nil {
+		return def, err
+	}
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return i, nil
+	}
+	// fractional or exponent number
+	r, err := p.toRat(raw, nil)
+	if err != nil {
+		return def, withExpected(err, def)
+	}
+	if r.IsInt() {
+		return new(big.Int).Set(r.Num()), nil
+	}
+	if p.Fraction == RejectFraction {
+		return def, fractionError(raw, def)
+	}
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if p.Fraction == Round && m.Abs(m).Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		// round half away from zero
+		q.Add(q, big.NewInt(int64(r.Num().Sign())))
+	}
+	return q, nil
+}
+
+func bigFloatConv(prec uint) func(ConvPolicy, interface{}, *big.Float) (*big.Float, error) {
+	return func(p ConvPolicy, raw interface{}, def *big.Float) (*big.Float, error) {
+		s, err := p.bigText(raw, def)
+		if err != nil {
+			return def, err
+		}
+		f, _, e := new(big.Float).SetPrec(prec).Parse(s, 10)
+		if e != nil {
+			return def, numberError(raw, def, e)
+		}
+		return f, nil
+	}
+}
+
+// decimal exponent of number s is not beyond maxBigExp
+func expInRange(s string) bool {
+	i := strings.IndexAny(s, "eE")
+	if i < 0 {
+		return true
+	}
+	exp, err := strconv.Atoi(s[i+1:])
+	return err == nil && exp <= maxBigExp && exp >= -maxBigExp
+}
+
+// replace the expected type of the error
+func withExpected(err error, expected interface{}) error {
+	if e, ok := err.(*PathError); ok {
+		e.Expected = typeName(expected)
+	}
+	return err
+}
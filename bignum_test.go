@@ -0,0 +1,95 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestBigNumber(t *testing.T) {
+	data := `{"id":340282366920938463463374607431768211455, "neg":-12345678901234567890123, "amount":"19.99",
+		"price":0.1, "frac":-2.5, "exp":1.5e3, "huge":1e100000, "a":{"b":[123456789012345678901]}, "s":"str"}`
+	jm, _ := jsonmap.Unmarshal([]byte(data), true)
+
+	id, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	if v, f, e := jm.GetBigInt("id", nil); v == nil || v.Cmp(id) != 0 || !f || e != nil {
+		t.Fatalf("GetBigInt failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("neg", nil); v == nil || v.String() != "-12345678901234567890123" || !f || e != nil {
+		t.Fatalf("GetBigInt by negative failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.RGetBigInt([]string{"a", "b", "0"}, nil); v == nil || v.String() != "123456789012345678901" || !f || e != nil {
+		t.Fatalf("RGetBigInt failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.DGetBigInt("exp", nil); v == nil || v.Int64() != 1500 || !f || e != nil {
+		t.Fatalf("DGetBigInt by 1.5e3 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("frac", nil); v == nil || v.Int64() != -2 || !f || e != nil {
+		t.Fatalf("GetBigInt by -2.5 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.Round}).GetBigInt("frac", nil); v == nil || v.Int64() != -3 || !f || e != nil {
+		t.Fatalf("Round GetBigInt by -2.5 failed: got (%v, %v, %v)", v, f, e)
+	}
+	def := big.NewInt(7)
+	if v, f, e := jm.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.RejectFraction}).GetBigInt("frac", def); v != def || !f || !errors.Is(e, jsonmap.ErrFraction) {
+		t.Fatalf("RejectFraction GetBigInt by -2.5 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("huge", def); v != def || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+		t.Fatalf("GetBigInt by 1e100000 failed: got (%v, %v, %v)", v, f, e)
+	}
+
+	if v, f, e := jm.GetRat("price", nil); v == nil || v.Cmp(big.NewRat(1, 10)) != 0 || !f || e != nil {
+		t.Fatalf("GetRat by 0.1 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetRat("amount", nil); v != nil || !f || !errors.Is(e, jsonmap.ErrTypeMismatch) {
+		t.Fatalf("GetRat by string failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.Coercing().GetRat("amount", nil); v == nil || v.Cmp(big.NewRat(1999, 100)) != 0 || !f || e != nil {
+		t.Fatalf("Coercing GetRat by \"19.99\" failed: got (%v, %v, %v)", v, f, e)
+	}
+
+	if v, f, e := jm.GetBigFloat("id", 200, nil); v == nil || v.Prec() != 200 || !f || e != nil {
+		t.Fatalf("GetBigFloat failed: got (%v, %v, %v)", v, f, e)
+	} else if i, _ := v.Int(nil); i.Cmp(id) != 0 {
+		t.Fatalf("GetBigFloat with prec 200 lost precision: got %v", i)
+	}
+	if v, f, e := jm.GetBigFloat("price", 0, nil); v == nil || v.Prec() != 64 || !f || e != nil {
+		t.Fatalf("GetBigFloat with prec 0 failed: got (%v, %v, %v)", v, f, e)
+	}
+
+	var pe *jsonmap.PathError
+	if v, f, e := jm.DGetBigInt("s", nil); v != nil || !f || !errors.Is(e, jsonmap.ErrTypeMismatch) || !errors.As(e, &pe) || pe.Expected != "*big.Int" {
+		t.Fatalf("DGetBigInt by string failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("none", def); v != def || f || e != nil {
+		t.Fatalf("GetBigInt by missing key failed: got (%v, %v, %v)", v, f, e)
+	}
+
+	// float64 is lossy
+	jm1 := jsonmap.JsonMap{"id": 3.4e38, "price": 0.1}
+	if v, f, e := jm1.GetBigInt("id", def); v != def || !f || !errors.Is(e, jsonmap.ErrPrecisionLoss) {
+		t.Fatalf("GetBigInt with useNumber=false failed: got (%v, %v, %v)", v, f, e)
+	}
+	if _, _, e := jm1.GetRat("price", nil); !errors.Is(e, jsonmap.ErrPrecisionLoss) {
+		t.Fatalf("GetRat with useNumber=false failed: got %v", e)
+	}
+	if _, _, e := jm1.GetBigFloat("price", 0, nil); !errors.Is(e, jsonmap.ErrPrecisionLoss) {
+		t.Fatalf("GetBigFloat with useNumber=false failed: got %v", e)
+	}
+}
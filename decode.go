@@ -0,0 +1,476 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Option configures UnmarshalWithOptions
+type Option func(*decodeOptions)
+
+type decodeOptions struct {
+	useNumber             bool
+	smartNumbers          bool
+	disallowDuplicateKeys bool
+	duplicates            *[]DuplicateKey
+	maxDepth              int
+	maxBytes              int
+	maxKeys               int
+	maxArrayLen           int
+	maxStringLen          int
+	policy                ConvPolicy
+}
+
+func newDecodeOptions(opts []Option) *decodeOptions {
+	o := &decodeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// decode numbers as json.Number, same as Unmarshal with useNumber=true
+func UseNumber() Option {
+	return func(o *decodeOptions) { o.useNumber = true }
+}
+
+// decode numbers the same as UnmarshalSmartNumbers, it overrides UseNumber
+func SmartNumbers() Option {
+	return func(o *decodeOptions) { o.smartNumbers = true }
+}
+
+// return a *DuplicateKeyError if an object has the same key twice, it overrides ReportDuplicateKeys
+func DisallowDuplicateKeys() Option {
+	return func(o *decodeOptions) { o.disallowDuplicateKeys = true }
+}
+
+// record duplicate keys in report instead of returning an error, the last value of the key is kept
+func ReportDuplicateKeys(report *[]DuplicateKey) Option {
+	return func(o *decodeOptions) { o.duplicates = report }
+}
+
+// max nesting depth of objects and arrays, the top level object is at depth 1, 0 means no limit
+func MaxDepth(n int) Option {
+	return func(o *decodeOptions) { o.maxDepth = n }
+}
+
+// max length of input data in bytes, 0 means no limit
+func MaxBytes(n int) Option {
+	return func(o *decodeOptions) { o.maxBytes = n }
+}
+
+// max number of keys in an object, 0 means no limit
+func MaxKeys(n int) Option {
+	return func(o *decodeOptions) { o.maxKeys = n }
+}
+
+// max number of items in an array, 0 means no limit
+func MaxArrayLen(n int) Option {
+	return func(o *decodeOptions) { o.maxArrayLen = n }
+}
+
+// max length of strings and keys in bytes of the raw input, excluding quotes, 0 means no limit
+func MaxStringLen(n int) Option {
+	return func(o *decodeOptions) { o.maxStringLen = n }
+}
+
+// conversion policy of the returned View
+func WithConvPolicy(p ConvPolicy) Option {
+	return func(o *decodeOptions) { o.policy = p }
+}
+
+// decode a json object with options, the returned View carries the conversion policy set by WithConvPolicy.
+// Like Unmarshal, the map may be partially filled if an error occurs.
+func UnmarshalWithOptions(data []byte, opts ...Option) (View, error) {
+	o := newDecodeOptions(opts)
+	m, err := o.unmarshal(data)
+	return newView(m, o.policy), err
+}
+
+// like UnmarshalWithOptions but reads data from r, MaxBytes is checked while reading
+func UnmarshalReader(r io.Reader, opts ...Option) (View, error) {
+	o := newDecodeOptions(opts)
+	data, err := o.readAll(r)
+	if err != nil {
+		return newView(nil, o.policy), err
+	}
+	m, err := o.unmarshal(data)
+	return newView(m, o.policy), err
+}
+
+// read all data from r, but not more than MaxBytes + 1
+func (o *decodeOptions) readAll(r io.Reader) ([]byte, error) {
+	if o.maxBytes > 0 {
+		r = io.LimitReader(r, int64(o.maxBytes)+1)
+	}
+	return io.ReadAll(r)
+}
+
+// decode any json value with options, the returned JsonValue carries the conversion policy set by WithConvPolicy
+func UnmarshalValue(data []byte, opts ...Option) (JsonValue, error) {
+	o := newDecodeOptions(opts)
+	v, err := o.unmarshalValue(data)
+	return JsonValue{v: v, found: true, policy: o.policy}, err
+}
+
+// data is not longer than MaxBytes
+func (o *decodeOptions) checkSize(data []byte) error {
+	if o.maxBytes > 0 && len(data) > o.maxBytes {
+		return &LimitError{Limit: "MaxBytes", Max: o.maxBytes, Offset: int64(o.maxBytes)}
+	}
+	return nil
+}
+
+// check and validate data for the scanners, return bounds of the top level value which must be an object
+func (o *decodeOptions) scanObject(data []byte) (start, end int, err error) {
+	if err = o.checkSize(data); err != nil {
+		return 0, 0, err
+	}
+	if !json.Valid(data) {
+		// get the detailed syntax error
+		var v interface{}
+		return 0, 0, json.Unmarshal(data, &v)
+	}
+	start = skipSpace(data, 0)
+	if data[start] != '{' {
+		return 0, 0, errNotObject
+	}
+	return start, skipValue(data, start), nil
+}
+
+func (o *decodeOptions) unmarshalValue(data []byte) (interface{}, error) {
+	if err := o.checkSize(data); err != nil {
+		return nil, err
+	}
+	if o.checkDuplicates() || o.limited() {
+		return o.newTokenDecoder(data).decode()
+	}
+	var v interface{}
+	var err error
+	if o.useNumber || o.smartNumbers {
+		err = JsonUnmarshalUseNumber(data, &v)
+	} else {
+		err = json.Unmarshal(data, &v)
+	}
+	if o.smartNumbers {
+		v = smartNumbers(v)
+	}
+	return v, err
+}
+
+func (o *decodeOptions) unmarshal(data []byte) (JsonMap, error) {
+	if err := o.checkSize(data); err != nil {
+		return nil, err
+	}
+	if o.checkDuplicates() || o.limited() {
+		return o.decodeTokens(data)
+	}
+	m := make(map[string]interface{})
+	var err error
+	if o.useNumber || o.smartNumbers {
+		err = JsonUnmarshalUseNumber(data, &m)
+	} else {
+		err = json.Unmarshal(data, &m)
+	}
+	if o.smartNumbers {
+		smartNumbers(m)
+	}
+	return m, err
+}
+
+func (o *decodeOptions) checkDuplicates() bool {
+	return o.disallowDuplicateKeys || o.duplicates != nil
+}
+
+// any limit needs to be checked while decoding
+func (o *decodeOptions) limited() bool {
+	return o.maxDepth > 0 || o.maxKeys > 0 || o.maxArrayLen > 0 || o.maxStringLen > 0
+}
+
+//// token stream decoder, it's used if any check needs to be done while decoding
+
+type tokenDecoder struct {
+	*decodeOptions
+	ordered bool // decode objects to *orderedObject
+	data    []byte
+	dec     *json.Decoder
+	keyPath []string
+	depth   int
+	base    int64 // offset of data in the whole input
+}
+
+var errNotObject = errors.New("jsonmap: top level value is not an object")
+
+func (o *decodeOptions) newTokenDecoder(data []byte) *tokenDecoder {
+	d := &tokenDecoder{decodeOptions: o, data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	d.dec.UseNumber()
+	return d
+}
+
+// decode the value data[start:end] which is at keyPath of the whole input, so limits count depth from the top level
+// and errors have the full key path and offsets in data
+func (o *decodeOptions) unmarshalAt(data []byte, start, end int, keyPath []string) (interface{}, error) {
+	if !o.checkDuplicates() && !o.limited() {
+		return o.unmarshalValue(data[start:end])
+	}
+	d := o.newTokenDecoder(data[start:end])
+	d.keyPath, d.depth, d.base = copyKeyPath(keyPath), len(keyPath), int64(start)
+	return d.decode()
+}
+
+// decode a json object
+func (o *decodeOptions) decodeTokens(data []byte) (JsonMap, error) {
+	v, err := o.newTokenDecoder(data).decode()
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, err
+	}
+	if v != nil {
+		return nil, errNotObject
+	}
+	return nil, err
+}
+
+// decode the top level value
+func (d *tokenDecoder) decode() (interface{}, error) {
+	tok, err := d.token()
+	if err != nil {
+		return nil, err
+	}
+	v, err := d.value(tok)
+	if err != nil {
+		return v, err
+	}
+	return v, d.end()
+}
+
+// no data after the top level value
+func (d *tokenDecoder) end() error {
+	if _, err := d.dec.Token(); err != io.EOF {
+		return fmt.Errorf("jsonmap: invalid data after top-level value at offset %d", d.offset())
+	}
+	return nil
+}
+
+func (d *tokenDecoder) value(tok json.Token) (interface{}, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return d.object()
+		}
+		return d.array()
+	case json.Number:
+		return d.number(t)
+	}
+	return tok, nil
+}
+
+// decode object, the '{' is consumed
+func (d *tokenDecoder) object() (interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	if d.ordered {
+		o := newOrderedObject()
+		return o, d.members(o.len, o.set)
+	}
+	m := make(map[string]interface{})
+	return m, d.members(func() int { return len(m) }, func(key string, v interface{}) { m[key] = v })
+}
+
+// decode members of object, size returns the number of keys set
+func (d *tokenDecoder) members(size func() int, set func(key string, v interface{})) error {
+	// byte offsets of keys, only used to check duplicates
+	var offsets map[string]int64
+	if d.checkDuplicates() {
+		offsets = make(map[string]int64)
+	}
+	for d.dec.More() {
+		if d.maxKeys > 0 && size() >= d.maxKeys {
+			return d.limitError("MaxKeys", d.maxKeys)
+		}
+		tok, err := d.token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if offsets != nil {
+			if err = d.checkKey(offsets, key); err != nil {
+				return err
+			}
+		}
+		d.keyPath = append(d.keyPath, key)
+		if tok, err = d.token(); err == nil {
+			var v interface{}
+			v, err = d.value(tok)
+			set(key, v)
+		}
+		d.keyPath = d.keyPath[:len(d.keyPath)-1]
+		if err != nil {
+			return err
+		}
+	}
+	return d.leave()
+}
+
+// decode array items, the '[' is consumed
+func (d *tokenDecoder) array() ([]interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	a := make([]interface{}, 0)
+	for i := 0; d.dec.More(); i++ {
+		if d.maxArrayLen > 0 && i >= d.maxArrayLen {
+			return a, d.limitError("MaxArrayLen", d.maxArrayLen)
+		}
+		d.keyPath = append(d.keyPath, strconv.Itoa(i))
+		tok, err := d.token()
+		if err == nil {
+			var v interface{}
+			v, err = d.value(tok)
+			a = append(a, v)
+		}
+		d.keyPath = d.keyPath[:len(d.keyPath)-1]
+		if err != nil {
+			return a, err
+		}
+	}
+	return a, d.leave()
+}
+
+// check whether key is duplicate in current object, key is the last read token
+func (d *tokenDecoder) checkKey(offsets map[string]int64, key string) error {
+	offset := d.keyOffset()
+	first, ok := offsets[key]
+	if !ok {
+		offsets[key] = offset
+		return nil
+	}
+	return d.duplicate(DuplicateKey{Path: append(copyKeyPath(d.keyPath), key), FirstOffset: first, Offset: offset})
+}
+
+// return the error of a duplicate key, or report it
+func (o *decodeOptions) duplicate(dup DuplicateKey) error {
+	if o.disallowDuplicateKeys {
+		return &DuplicateKeyError{dup}
+	}
+	*o.duplicates = append(*o.duplicates, dup)
+	return nil
+}
+
+// offset of the opening quote of the last read key, the decoder is just after the closing quote
+func (d *tokenDecoder) keyOffset() int64 {
+	i := int(d.dec.InputOffset()) - 2
+	for ; i > 0; i-- {
+		if d.data[i] != '"' {
+			continue
+		}
+		// the quote is escaped if it's after odd number of backslashes
+		j := i - 1
+		for j >= 0 && d.data[j] == '\\' {
+			j--
+		}
+		if (i-j)%2 == 1 {
+			break
+		}
+	}
+	return d.base + int64(i)
+}
+
+func (d *tokenDecoder) enter() error {
+	d.depth++
+	if d.maxDepth > 0 && d.depth > d.maxDepth {
+		return d.limitError("MaxDepth", d.maxDepth)
+	}
+	return nil
+}
+
+// read next token, strings are checked by MaxStringLen before they are decoded
+func (d *tokenDecoder) token() (json.Token, error) {
+	if d.maxStringLen > 0 {
+		if offset, n := d.nextString(); n > d.maxStringLen {
+			err := d.limitError("MaxStringLen", d.maxStringLen)
+			err.Offset = d.base + offset
+			return nil, err
+		}
+	}
+	return d.dec.Token()
+}
+
+// offset and raw length of the next token if it's a string, or length -1
+func (d *tokenDecoder) nextString() (offset int64, n int) {
+	i := int(d.dec.InputOffset())
+	for i < len(d.data) && strings.IndexByte(" \t\r\n,:", d.data[i]) >= 0 {
+		i++
+	}
+	if i >= len(d.data) || d.data[i] != '"' {
+		return 0, -1
+	}
+	for j := i + 1; j < len(d.data); j++ {
+		switch d.data[j] {
+		case '\\':
+			j++
+		case '"':
+			return int64(i), j - i - 1
+		}
+	}
+	return 0, -1
+}
+
+func (d *tokenDecoder) limitError(limit string, max int) *LimitError {
+	return &LimitError{Limit: limit, Max: max, Path: copyKeyPath(d.keyPath), Offset: d.offset()}
+}
+
+// offset in the whole input
+func (d *tokenDecoder) offset() int64 {
+	return d.base + d.dec.InputOffset()
+}
+
+// consume the closing delimiter
+func (d *tokenDecoder) leave() error {
+	d.depth--
+	_, err := d.dec.Token()
+	return err
+}
+
+func (d *tokenDecoder) number(n json.Number) (interface{}, error) {
+	v, err := d.decodeOptions.number(n)
+	if e, ok := err.(*json.UnmarshalTypeError); ok {
+		e.Offset = d.offset()
+	}
+	return v, err
+}
+
+// decode number by options
+func (o *decodeOptions) number(n json.Number) (interface{}, error) {
+	switch {
+	case o.smartNumbers:
+		return smartNumber(n), nil
+	case o.useNumber:
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, &json.UnmarshalTypeError{Value: "number " + string(n), Type: reflect.TypeOf(f)}
+	}
+	return f, nil
+}
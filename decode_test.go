@@ -0,0 +1,168 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	data := `{"a":{"b":[1, 2.5, {"c":"x"}]}, "long":7095620078347567873, "n":null, "t":true}`
+	checks := [][]jsonmap.Option{
+		nil,
+		{jsonmap.MaxDepth(4)},
+		{jsonmap.DisallowDuplicateKeys()},
+	}
+	for _, opts := range checks {
+		v, err := jsonmap.UnmarshalWithOptions([]byte(data), opts...)
+		expected, _ := jsonmap.Unmarshal([]byte(data), false)
+		if err != nil || !reflect.DeepEqual(v.Map(), expected) {
+			t.Fatalf("UnmarshalWithOptions failed: got (%v, %v), expect %v", v, err, expected)
+		}
+
+		v, err = jsonmap.UnmarshalWithOptions([]byte(data), append(opts, jsonmap.UseNumber())...)
+		expected, _ = jsonmap.Unmarshal([]byte(data), true)
+		if err != nil || !reflect.DeepEqual(v.Map(), expected) {
+			t.Fatalf("UnmarshalWithOptions with UseNumber failed: got (%v, %v), expect %v", v, err, expected)
+		}
+		if n, _, _ := v.RGet([]string{"a", "b", "1"}, nil); n != json.Number("2.5") {
+			t.Fatalf("UseNumber failed: got %T %v", n, n)
+		}
+
+		v, err = jsonmap.UnmarshalWithOptions([]byte(data), append(opts, jsonmap.SmartNumbers(), jsonmap.UseNumber())...)
+		expected, _ = jsonmap.UnmarshalSmartNumbers([]byte(data))
+		if err != nil || !reflect.DeepEqual(v.Map(), expected) {
+			t.Fatalf("UnmarshalWithOptions with SmartNumbers failed: got (%v, %v), expect %v", v, err, expected)
+		}
+		if i, _, _ := v.Get("long", nil); i != int64(7095620078347567873) {
+			t.Fatalf("SmartNumbers failed: got %T %v", i, i)
+		}
+	}
+
+	// the policy is stored in the view
+	v, _ := jsonmap.UnmarshalWithOptions([]byte(`{"f":1.5, "s":"1"}`), jsonmap.WithConvPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.Round, Coerce: true}))
+	if i, f, e := v.GetInt("f", 0); i != 2 || !f || e != nil {
+		t.Fatalf("GetInt with Round policy failed: got (%v, %v, %v)", i, f, e)
+	}
+	if i, f, e := v.GetInt("s", 0); i != 1 || !f || e != nil {
+		t.Fatalf("GetInt with Coerce policy failed: got (%v, %v, %v)", i, f, e)
+	}
+
+	errCases := []struct {
+		data string
+		opts []jsonmap.Option
+		msg  string
+	}{
+		{`{"a":1, "a":2}`, []jsonmap.Option{jsonmap.DisallowDuplicateKeys()}, `key "/a": duplicate key`},
+		{`{"a":[{"b":1, "c":{}, "b":2}]}`, []jsonmap.Option{jsonmap.DisallowDuplicateKeys()}, `key "/a/0/b": duplicate key`},
+		{`{"a":{"b":{"c":1}}}`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `MaxDepth 2`},
+		{`{"a":[[1]]}`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `MaxDepth 2`},
+		{`{"a":1}`, []jsonmap.Option{jsonmap.MaxBytes(5)}, `MaxBytes 5`},
+		{`[1]`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `not an object`},
+		{`{"a":1} {}`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `after top-level value`},
+		{`{"a":1e1000}`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `cannot unmarshal number`},
+		{`{"a":}`, []jsonmap.Option{jsonmap.MaxDepth(2)}, `missing value`},
+	}
+	for _, c := range errCases {
+		if _, err := jsonmap.UnmarshalWithOptions([]byte(c.data), c.opts...); err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Fatalf("UnmarshalWithOptions by %s should fail with %q: got %v", c.data, c.msg, err)
+		}
+	}
+	if _, err := jsonmap.UnmarshalWithOptions([]byte(`{"a":{"b":1}}`), jsonmap.MaxDepth(2), jsonmap.MaxBytes(100)); err != nil {
+		t.Fatalf("UnmarshalWithOptions within limits failed: %v", err)
+	}
+	if v, err := jsonmap.UnmarshalWithOptions([]byte(`null`), jsonmap.MaxDepth(2)); err != nil || v.Map() != nil {
+		t.Fatalf("UnmarshalWithOptions by null failed: got (%v, %v)", v, err)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	data := `{"a":1, "b":{"x\\\"y":1, "c":[{"d":1, "d":2}], "x\\\"y":2}, "a":3}`
+	_, err := jsonmap.UnmarshalWithOptions([]byte(data), jsonmap.DisallowDuplicateKeys())
+	var de *jsonmap.DuplicateKeyError
+	if !errors.Is(err, jsonmap.ErrDuplicateKey) || !errors.As(err, &de) {
+		t.Fatalf("DisallowDuplicateKeys failed: got %v", err)
+	}
+	if !reflect.DeepEqual(de.Path, []string{"b", "c", "0", "d"}) || de.FirstOffset != 31 || de.Offset != 38 {
+		t.Fatalf("DisallowDuplicateKeys failed: got %#v", de)
+	}
+	if de.Error() != `jsonmap: key "/b/c/0/d": duplicate key at offset 38, first at offset 31` {
+		t.Fatalf("DuplicateKeyError.Error failed: got %s", de.Error())
+	}
+
+	var report []jsonmap.DuplicateKey
+	v, err := jsonmap.UnmarshalWithOptions([]byte(data), jsonmap.ReportDuplicateKeys(&report))
+	if err != nil {
+		t.Fatalf("ReportDuplicateKeys failed: %v", err)
+	}
+	expected := []jsonmap.DuplicateKey{
+		{Path: []string{"b", "c", "0", "d"}, FirstOffset: 31, Offset: 38},
+		{Path: []string{"b", `x\"y`}, FirstOffset: 13, Offset: 47},
+		{Path: []string{"a"}, FirstOffset: 1, Offset: 60},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("ReportDuplicateKeys failed: got %#v", report)
+	}
+	for _, d := range report {
+		if data[d.FirstOffset] != '"' || data[d.Offset] != '"' {
+			t.Fatalf("offsets should point to the opening quote of keys: got %#v", d)
+		}
+	}
+	if a, _, _ := v.GetInt("a", 0); a != 3 {
+		t.Fatalf("the last value of duplicate key should be kept: got %v", a)
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	data := `{"a":{"b":[1, 2, 3], "c":"abc\"d"}, "e":[{}, []], "key":1}`
+	cases := []struct {
+		opt    jsonmap.Option
+		limit  string
+		path   []string
+		offset int64
+	}{
+		{jsonmap.MaxDepth(2), "MaxDepth", []string{"a", "b"}, 11},
+		{jsonmap.MaxBytes(10), "MaxBytes", nil, 10},
+		{jsonmap.MaxKeys(2), "MaxKeys", []string{}, 48},
+		{jsonmap.MaxArrayLen(2), "MaxArrayLen", []string{"a", "b"}, 15},
+		{jsonmap.MaxStringLen(4), "MaxStringLen", []string{"a", "c"}, 25},
+	}
+	for _, c := range cases {
+		_, err := jsonmap.UnmarshalWithOptions([]byte(data), c.opt)
+		var le *jsonmap.LimitError
+		if !errors.Is(err, jsonmap.ErrLimitExceeded) || !errors.As(err, &le) {
+			t.Fatalf("%s failed: got %v", c.limit, err)
+		}
+		if le.Limit != c.limit || !reflect.DeepEqual(le.Path, c.path) || le.Offset != c.offset {
+			t.Fatalf("%s failed: got %#v", c.limit, le)
+		}
+	}
+	opts := []jsonmap.Option{jsonmap.MaxDepth(3), jsonmap.MaxBytes(len(data)), jsonmap.MaxKeys(3),
+		jsonmap.MaxArrayLen(3), jsonmap.MaxStringLen(6)}
+	if v, err := jsonmap.UnmarshalWithOptions([]byte(data), opts...); err != nil || len(v.Map()) != 3 {
+		t.Fatalf("decode within limits failed: got (%v, %v)", v, err)
+	}
+
+	_, err := jsonmap.UnmarshalWithOptions([]byte(`{"a":[[[]]]}`), jsonmap.MaxDepth(3))
+	if err == nil || err.Error() != `jsonmap: key "/a/0/0": limit exceeded: MaxDepth 3 at offset 8` {
+		t.Fatalf("LimitError.Error failed: got %v", err)
+	}
+}
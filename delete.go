@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"strconv"
+)
+
+// DeleteOption configures RDelete and DeletePaths
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	prune bool
+}
+
+// remove parent maps and arrays which become empty by the deletion, up to but not including the root
+func PruneEmpty() DeleteOption {
+	return func(o *deleteOptions) { o.prune = true }
+}
+
+// remove key, return false if the key doesn't exist
+func (d JsonMap) Delete(key string) bool {
+	if _, ok := d[key]; !ok {
+		return false
+	}
+	delete(d, key)
+	return true
+}
+
+// remove the value at keyPath, an array element is removed by index and later elements move forward.
+// Return false if nothing is removed, e.g. keyPath is empty, missing, or goes through a scalar.
+func (d JsonMap) RDelete(keyPath []string, opts ...DeleteOption) bool {
+	return d.DeletePaths([][]string{keyPath}, opts...) > 0
+}
+
+// remove values at keyPaths in one walk, return the number of removed values.
+// Indexes of an array refer to positions before any of its elements are removed.
+func (d JsonMap) DeletePaths(keyPaths [][]string, opts ...DeleteOption) int {
+	var o deleteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root := &pathNode{}
+	for _, keyPath := range keyPaths {
+		if len(keyPath) > 0 {
+			root.add(keyPath)
+		}
+	}
+	_, removed, _ := o.deleteIn(map[string]interface{}(d), root)
+	return removed
+}
+
+// remove values of n's leaves from v, return the new v, number of removed values and whether v should be pruned
+func (o *deleteOptions) deleteIn(v interface{}, n *pathNode) (nv interface{}, removed int, prune bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, c := range n.children {
+			child, ok := t[key]
+			if !ok {
+				continue
+			}
+			if c.leaf {
+				delete(t, key)
+				removed++
+				continue
+			}
+			nc, r, p := o.deleteIn(child, c)
+			if p {
+				delete(t, key)
+			} else if r > 0 {
+				t[key] = nc
+			}
+			removed += r
+		}
+		return t, removed, o.prune && removed > 0 && len(t) == 0
+	case []interface{}:
+		drop := make(map[int]bool)
+		for key, c := range n.children {
+			i, err := strconv.Atoi(key)
+			if err != nil {
+				continue
+			}
+			j, ok := arrayIndex(i, len(t))
+			if !ok || drop[j] {
+				continue
+			}
+			if c.leaf {
+				drop[j] = true
+				removed++
+				continue
+			}
+			nc, r, p := o.deleteIn(t[j], c)
+			if p {
+				drop[j] = true
+			} else if r > 0 {
+				t[j] = nc
+			}
+			removed += r
+		}
+		if len(drop) == 0 {
+			return t, removed, false
+		}
+		a := make([]interface{}, 0, len(t)-len(drop))
+		for j, item := range t {
+			if !drop[j] {
+				a = append(a, item)
+			}
+		}
+		return a, removed, o.prune && len(a) == 0
+	}
+	return v, 0, false
+}
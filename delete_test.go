@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestRDelete(t *testing.T) {
+	data := `{"a":{"b":{"c":1}, "d":2}, "arr":[0, {"x":1, "y":2}, 2], "s":"s"}`
+	jm, _ := jsonmap.Unmarshal([]byte(data), false)
+	if !jm.Delete("s") || jm.Delete("s") {
+		t.Fatal("Delete failed")
+	}
+	if !jm.RDelete([]string{"a", "b", "c"}) || jm.String() != `{"a":{"b":{},"d":2},"arr":[0,{"x":1,"y":2},2]}` {
+		t.Fatalf("RDelete failed: got %s", jm)
+	}
+	if !jm.RDelete([]string{"arr", "-1"}) || jm.String() != `{"a":{"b":{},"d":2},"arr":[0,{"x":1,"y":2}]}` {
+		t.Fatalf("RDelete array item failed: got %s", jm)
+	}
+	for _, keyPath := range [][]string{nil, {"no"}, {"a", "d", "x"}, {"arr", "x"}, {"arr", "5"}, {"a", "b", "c"}} {
+		if jm.RDelete(keyPath) {
+			t.Fatalf("RDelete %v should remove nothing", keyPath)
+		}
+	}
+
+	// prune parents which become empty, but not the ones already empty
+	if !jm.RDelete([]string{"arr", "1", "x"}, jsonmap.PruneEmpty()) || jm.String() != `{"a":{"b":{},"d":2},"arr":[0,{"y":2}]}` {
+		t.Fatalf("RDelete with PruneEmpty failed: got %s", jm)
+	}
+	if !jm.RDelete([]string{"arr", "1", "y"}, jsonmap.PruneEmpty()) || jm.String() != `{"a":{"b":{},"d":2},"arr":[0]}` {
+		t.Fatalf("RDelete with PruneEmpty failed: got %s", jm)
+	}
+	if !jm.RDelete([]string{"arr", "0"}, jsonmap.PruneEmpty()) || jm.String() != `{"a":{"b":{},"d":2}}` {
+		t.Fatalf("RDelete with PruneEmpty failed: got %s", jm)
+	}
+	if !jm.RDelete([]string{"a", "d"}, jsonmap.PruneEmpty()) || jm.String() != `{"a":{"b":{}}}` {
+		t.Fatalf("RDelete with PruneEmpty failed: got %s", jm)
+	}
+}
+
+func TestDeletePaths(t *testing.T) {
+	data := `{"user":{"name":"n", "password":"p", "token":"t"}, "list":[{"id":1, "secret":1}, {"secret":2}, 3, 4], "x":1}`
+	jm, _ := jsonmap.Unmarshal([]byte(data), false)
+	n := jm.DeletePaths([][]string{
+		{"user", "password"}, {"user", "token"}, {"list", "0", "secret"}, {"list", "1", "secret"},
+		{"list", "2"}, {"list", "-1"}, {"x"}, {"x", "y"}, {"missing"}, {},
+	})
+	if n != 7 || jm.String() != `{"list":[{"id":1},{}],"user":{"name":"n"}}` {
+		t.Fatalf("DeletePaths failed: got (%d, %s)", n, jm)
+	}
+
+	jm, _ = jsonmap.Unmarshal([]byte(data), false)
+	n = jm.DeletePaths([][]string{{"user", "name"}, {"user", "password"}, {"user", "token"}, {"list", "1", "secret"}}, jsonmap.PruneEmpty())
+	if n != 4 || jm.String() != `{"list":[{"id":1,"secret":1},3,4],"x":1}` {
+		t.Fatalf("DeletePaths with PruneEmpty failed: got (%d, %s)", n, jm)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//// dotted path like `a.b[2].c` or `a["x.y"].z`
+
+// parse a dotted path to a key path which can be used by RGet*.
+// keys are separated by '.', array index is in brackets like `[2]` or `[-1]`,
+// keys containing '.', '[', ']' or quotes should be quoted in brackets like `["x.y"]` or `['x.y']`,
+// and backslash escapes are supported in quoted keys.
+func ParsePath(path string) (keyPath []string, err error) {
+	if path == "" {
+		return nil, emptyPathError()
+	}
+	keyPath = make([]string, 0, strings.Count(path, ".")+strings.Count(path, "[")+1)
+	for i := 0; i < len(path); {
+		switch c := path[i]; {
+		case c == '[':
+			key, n, err := parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			keyPath = append(keyPath, key)
+			i += n
+		case c == '.' && i == 0:
+			return nil, pathSyntaxError(path, i, "unexpected '.'")
+		case c == ']' || c == '"' || c == '\'':
+			return nil, pathSyntaxError(path, i, fmt.Sprintf("unexpected %q", c))
+		default:
+			if i > 0 {
+				if c != '.' {
+					return nil, pathSyntaxError(path, i, "expect '.' or '['")
+				}
+				i++
+			}
+			j := i
+			for j < len(path) && !strings.ContainsRune(".[]\"'", rune(path[j])) {
+				j++
+			}
+			if j == i {
+				return nil, pathSyntaxError(path, i, "empty key")
+			}
+			keyPath = append(keyPath, path[i:j])
+			i = j
+		}
+	}
+	return keyPath, nil
+}
+
+// parse the bracket segment starting at path[i], returns the key and the length of the segment
+func parseBracket(path string, i int) (key string, n int, err error) {
+	j := i + 1
+	if j < len(path) && (path[j] == '"' || path[j] == '\'') {
+		key, end, err := scanQuoted(path, j)
+		if err != nil {
+			return "", 0, pathSyntaxError(path, j, err.Error())
+		}
+		if end >= len(path) || path[end] != ']' {
+			return "", 0, pathSyntaxError(path, end, "expect ']'")
+		}
+		return key, end + 1 - i, nil
+	}
+	k := strings.IndexByte(path[j:], ']')
+	if k < 0 {
+		return "", 0, pathSyntaxError(path, i, "unterminated '['")
+	}
+	key = path[j : j+k]
+	if _, err := strconv.Atoi(key); err != nil {
+		return "", 0, pathSyntaxError(path, j, fmt.Sprintf("index %q is not integer", key))
+	}
+	return key, k + 2, nil
+}
+
+// scan the double or single quoted string starting at s[i],
+// returns the unquoted string and the offset after the closing quote
+func scanQuoted(s string, i int) (str string, end int, err error) {
+	quote := s[i]
+	k := i + 1
+	for ; k < len(s) && s[k] != quote; k++ {
+		if s[k] == '\\' {
+			k++
+		}
+	}
+	if k >= len(s) {
+		return "", 0, errors.New("unterminated quoted string")
+	}
+	str, err = unquoteKey(s[i : k+1])
+	if err != nil {
+		return "", 0, errors.New("invalid quoted string")
+	}
+	return str, k + 1, nil
+}
+
+// unquote a double or single quoted key
+func unquoteKey(s string) (string, error) {
+	if s[0] == '"' {
+		return strconv.Unquote(s)
+	}
+	// convert to double quoted
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 1; i < len(s)-1; i++ {
+		switch {
+		case s[i] == '\\' && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case s[i] == '\\':
+			b.WriteByte(s[i])
+			b.WriteByte(s[i+1])
+			i++
+		case s[i] == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	b.WriteByte('"')
+	return strconv.Unquote(b.String())
+}
+
+func pathSyntaxError(path string, offset int, msg string) error {
+	return fmt.Errorf("path %q syntax error at offset %d: %s", path, offset, msg)
+}
+
+// cache of parsed paths, paths are not cached anymore when the cache is full
+var (
+	pathCache     sync.Map
+	pathCacheSize int32
+)
+
+const maxPathCacheSize = 4096
+
+// same as ParsePath but the result is cached and should not be modified
+func parsePathCached(path string) ([]string, error) {
+	if keyPath, ok := pathCache.Load(path); ok {
+		return keyPath.([]string), nil
+	}
+	keyPath, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.LoadInt32(&pathCacheSize) < maxPathCacheSize {
+		if _, loaded := pathCache.LoadOrStore(path, keyPath); !loaded {
+			atomic.AddInt32(&pathCacheSize, 1)
+		}
+	}
+	return keyPath, nil
+}
+
+//// get by dotted path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+// fetch origin value, no type assurance
+func (d JsonMap) DGet(path string, def interface{}) (val interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return d.RGet(keyPath, def)
+}
+
+// this method ensures val’s type is same as def
+func (d JsonMap) DGetAny(path string, def interface{}) (val interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return d.RGetAny(keyPath, def)
+}
+
+func (d JsonMap) DGetSubMap(path string, def JsonMap) (val JsonMap, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return d.RGetSubMap(keyPath, def)
+}
+
+// get value with type T by dotted path
+func DGet[T Scalar](d Getter, path string, def T) (val T, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return RGet(d, keyPath, def)
+}
+
+// get slice with item type T by dotted path
+func DGetSlice[T Scalar](d Getter, path string, def []T) (val []T, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return RGetSlice(d, keyPath, def)
+}
+
+func (d JsonMap) DGetString(path string, def string) (val string, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetBool(path string, def bool) (val bool, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetFloat64(path string, def float64) (val float64, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetFloat32(path string, def float32) (val float32, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetInt64(path string, def int64) (val int64, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetUint64(path string, def uint64) (val uint64, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetInt32(path string, def int32) (val int32, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetUint32(path string, def uint32) (val uint32, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetInt(path string, def int) (val int, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+func (d JsonMap) DGetUint(path string, def uint) (val uint, found bool, err error) {
+	return DGet(d, path, def)
+}
+
+//// get slice by dotted path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (d JsonMap) DGetSlice(path string, def []interface{}) (val []interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return d.RGetSlice(keyPath, def)
+}
+
+func (d JsonMap) DGetAnySlice(path string, def []interface{}, itemType interface{}) (
+	val []interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return d.RGetAnySlice(keyPath, def, itemType)
+}
+
+func (d JsonMap) DGetStringSlice(path string, def []string) (val []string, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetBoolSlice(path string, def []bool) (val []bool, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetFloat64Slice(path string, def []float64) (val []float64, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetFloat32Slice(path string, def []float32) (val []float32, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetInt64Slice(path string, def []int64) (val []int64, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetUint64Slice(path string, def []uint64) (val []uint64, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetInt32Slice(path string, def []int32) (val []int32, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetUint32Slice(path string, def []uint32) (val []uint32, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetIntSlice(path string, def []int) (val []int, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
+
+func (d JsonMap) DGetUintSlice(path string, def []uint) (val []uint, found bool, err error) {
+	return DGetSlice(d, path, def)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		path    string
+		keyPath []string
+		hasErr  bool
+	}{
+		{"a", []string{"a"}, false},
+		{"a.b.c", []string{"a", "b", "c"}, false},
+		{"a.b[2].c", []string{"a", "b", "2", "c"}, false},
+		{"a[-1][0]", []string{"a", "-1", "0"}, false},
+		{`[0].a`, []string{"0", "a"}, false},
+		{`a["x.y"].z`, []string{"a", "x.y", "z"}, false},
+		{`a['x.y']['[1]']`, []string{"a", "x.y", "[1]"}, false},
+		{`a["q\"\\"]`, []string{"a", `q"\`}, false},
+		{`a['it\'s "x"']`, []string{"a", `it's "x"`}, false},
+		{"", nil, true},
+		{".a", nil, true},
+		{"a.", nil, true},
+		{"a..b", nil, true},
+		{"a[x]", nil, true},
+		{"a[1", nil, true},
+		{"a[1]b", nil, true},
+		{"a]", nil, true},
+		{`a["x`, nil, true},
+		{`a["x"`, nil, true},
+		{`a"x"`, nil, true},
+	}
+	for _, c := range cases {
+		keyPath, err := jsonmap.ParsePath(c.path)
+		if !reflect.DeepEqual(keyPath, c.keyPath) || bool(err != nil) != c.hasErr {
+			t.Fatalf("ParsePath(%q) failed: got (%q, %v), expect (%q, hasErr:%v)", c.path, keyPath, err, c.keyPath, c.hasErr)
+		}
+	}
+}
+
+func TestDGet(t *testing.T) {
+	data := `{"a":{"b":[{"c":1},{"c":2},{"c":3,"x.y":["s"]}]}}`
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(data), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v, string = %v", err, useNumber, data)
+		}
+		if v, f, e := jm.DGetInt("a.b[2].c", 0); v != 3 || !f || e != nil {
+			t.Fatalf("DGetInt failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.DGetStringSlice(`a.b[-1]["x.y"]`, nil); len(v) != 1 || v[0] != "s" || !f || e != nil {
+			t.Fatalf("DGetStringSlice failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.DGetInt("a.b[3].c", -1); v != -1 || f || e != nil {
+			t.Fatalf("DGetInt failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.DGetInt("a.b[3", -1); v != -1 || f || e == nil {
+			t.Fatalf("DGetInt failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jsonmap.DGet(jm, "a.b[0].c", uint(0)); v != 1 || !f || e != nil {
+			t.Fatalf("DGet[uint] failed: got (%v, %v, %v)", v, f, e)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sentinel errors, use errors.Is to check them
+var (
+	// value can't be converted to the expected type
+	ErrTypeMismatch = errors.New("type mismatch")
+	// value is not a map, or not a map or array when it's looked up by key
+	ErrNotMap = errors.New("not map")
+	// key path is empty
+	ErrEmptyPath = errors.New("empty path")
+	// number is out of range of the expected type
+	ErrOverflow = errors.New("overflow")
+	// array is looked up by a key which is not an index, e.g. "x" or "01" in JSON Pointer
+	ErrInvalidIndex = errors.New("invalid index")
+	// array index is out of range when the element must exist, e.g. RSet or ApplyPatch, getters report it as not found
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// fractional number is converted to integer with policy RejectFraction
+	ErrFraction = errors.New("fractional number")
+	// exact number is required but the value is float64, which is decoded with useNumber=false
+	ErrPrecisionLoss = errors.New("precision loss")
+	// an object has the same key twice, it's returned by decoding with DisallowDuplicateKeys
+	ErrDuplicateKey = errors.New("duplicate key")
+	// input exceeds a decode limit, e.g. MaxDepth
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// value at a key path doesn't exist, it's returned by patch operations which require the value
+	ErrNotFound = errors.New("not found")
+	// patch document is malformed, e.g. an operation has unknown op or misses a member
+	ErrInvalidPatch = errors.New("invalid patch")
+	// test operation of a patch fails
+	ErrTestFailed = errors.New("test failed")
+)
+
+// PathError is the error returned by getters
+type PathError struct {
+	Path     []string // full key path, array index is in decimal
+	Index    int      // index of the failing segment in Path, the value at Path[0:Index+1] causes the error, -1 if none
+	Expected string   // expected type, e.g. "int", "map"
+	Actual   string   // actual type, e.g. "string", "json.Number"
+	Err      error    // one of the sentinel errors
+	Cause    error    // underlying error if any, e.g. *strconv.NumError
+}
+
+func (e *PathError) Error() string {
+	var b strings.Builder
+	b.WriteString("jsonmap: ")
+	if len(e.Path) > 0 {
+		fmt.Fprintf(&b, "key %q: ", FormatPointer(e.Path))
+	}
+	b.WriteString(e.Err.Error())
+	if e.Index >= 0 && e.Index < len(e.Path)-1 {
+		fmt.Fprintf(&b, " at %q", FormatPointer(e.Path[0:e.Index+1]))
+	}
+	if e.Actual != "" || e.Expected != "" {
+		fmt.Fprintf(&b, ": got %s but expected %s", e.Actual, e.Expected)
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(&b, ": %v", e.Cause)
+	}
+	return b.String()
+}
+
+// errors.Is(err, sentinel) reports whether the error is of the sentinel kind
+func (e *PathError) Is(target error) bool {
+	return target == e.Err
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Cause
+}
+
+// DuplicateKey is a key that appears more than once in an object
+type DuplicateKey struct {
+	Path        []string // full key path of the key
+	FirstOffset int64    // byte offset of the first occurrence of the key in input
+	Offset      int64    // byte offset of the duplicate
+}
+
+// DuplicateKeyError is returned by decoding with DisallowDuplicateKeys
+type DuplicateKeyError struct {
+	DuplicateKey
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("jsonmap: key %q: duplicate key at offset %d, first at offset %d",
+		FormatPointer(e.Path), e.Offset, e.FirstOffset)
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+// LimitError is returned by decoding if the input exceeds a limit
+type LimitError struct {
+	Limit  string   // name of the option, e.g. "MaxDepth", "MaxBytes"
+	Max    int      // value of the limit
+	Path   []string // key path of the value exceeding the limit, e.g. the array exceeding MaxArrayLen
+	Offset int64    // byte offset in input where the limit is hit
+}
+
+func (e *LimitError) Error() string {
+	var b strings.Builder
+	b.WriteString("jsonmap: ")
+	if len(e.Path) > 0 {
+		fmt.Fprintf(&b, "key %q: ", FormatPointer(e.Path))
+	}
+	fmt.Fprintf(&b, "%s: %s %d at offset %d", ErrLimitExceeded, e.Limit, e.Max, e.Offset)
+	return b.String()
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// LineError is the error of a line returned by LineDecoder
+type LineError struct {
+	Line int // 1-based line number
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("jsonmap: line %d: %s", e.Line, strings.TrimPrefix(e.Err.Error(), "jsonmap: "))
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// PatchError is the error of an operation returned by ApplyPatch
+type PatchError struct {
+	Index int    // 0-based index of the operation in the patch
+	Op    string // op of the operation
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("jsonmap: patch operation %d (%s): %s", e.Index, e.Op, strings.TrimPrefix(e.Err.Error(), "jsonmap: "))
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+func emptyPathError() error {
+	return &PathError{Index: -1, Err: ErrEmptyPath}
+}
+
+// keyPath[idx] can't be looked up in v, which is the value at keyPath[0:idx]
+func lookupError(keyPath []string, idx int, v interface{}) error {
+	e := &PathError{Path: copyKeyPath(keyPath), Index: idx - 1, Err: ErrNotMap, Expected: "map or array", Actual: typeName(v)}
+	if _, ok := v.([]interface{}); ok {
+		e.Err, e.Expected, e.Actual = ErrInvalidIndex, "", ""
+	}
+	return e
+}
+
+// value at the end of keyPath is not a map
+func notMapError(keyPath []string, raw interface{}) error {
+	return &PathError{Path: copyKeyPath(keyPath), Index: len(keyPath) - 1, Err: ErrNotMap, Expected: "map", Actual: typeName(raw)}
+}
+
+// type error without key path, the getter should fill it by withPath
+func typeError(raw, expected interface{}) *PathError {
+	return &PathError{Index: -1, Err: ErrTypeMismatch, Expected: typeName(expected), Actual: typeName(raw)}
+}
+
+// number is out of range of expected type
+func overflowError(raw, expected interface{}) error {
+	return &PathError{Index: -1, Err: ErrOverflow, Expected: typeName(expected), Actual: typeName(raw)}
+}
+
+// fractional number is rejected to convert to integer
+func fractionError(raw, expected interface{}) error {
+	return &PathError{Index: -1, Err: ErrFraction, Expected: typeName(expected), Actual: typeName(raw)}
+}
+
+// float64 can't be converted to exact numbers
+func precisionLossError(raw, expected interface{}) error {
+	return &PathError{Index: -1, Err: ErrPrecisionLoss, Expected: typeName(expected), Actual: typeName(raw)}
+}
+
+// error of parsing json.Number by strconv
+func numberError(raw, expected interface{}, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := typeError(raw, expected)
+	e.Cause = err
+	if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+		e.Err = ErrOverflow
+	}
+	return e
+}
+
+// fill key path of the error returned by type conversion, the error is from the value at keyPath + extra
+func withPath(err error, keyPath []string, extra ...string) error {
+	if e, ok := err.(*PathError); ok && e.Path == nil && e.Err != ErrEmptyPath {
+		e.Path = append(copyKeyPath(keyPath), extra...)
+		e.Index = len(e.Path) - 1
+	}
+	return err
+}
+
+// extend key path of the error by an index in slice
+func withIndex(err error, keyPath []string, idx int) error {
+	return withPath(err, keyPath, strconv.Itoa(idx))
+}
+
+// fill key path of the error returned by convertSlice, idx is -1 if the value itself is not a slice
+func withSliceIndex(err error, keyPath []string, idx int) error {
+	if idx < 0 {
+		return withPath(err, keyPath)
+	}
+	return withIndex(err, keyPath, idx)
+}
+
+func copyKeyPath(keyPath []string) []string {
+	return append(make([]string, 0, len(keyPath)+1), keyPath...)
+}
+
+func typeName(v interface{}) string {
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"reflect"
+)
+
+// Scalar is the set of types supported by the typed getters,
+// named types with one of these underlying types are supported too
+type Scalar interface {
+	~string | ~bool | ~float64 | ~float32 | ~int64 | ~uint64 | ~int32 | ~uint32 | ~int | ~uint
+}
+
+// Getter is the source of generic getters, it's implemented by JsonMap, View and JsonValue
+type Getter interface {
+	// look up the raw value by key path, returns the root if keyPath is empty
+	lookup(keyPath []string) (val interface{}, found bool, err error)
+	// same as lookup but keyPath is parsed from a JSON Pointer, so array indexes are strict
+	pointerLookup(keyPath []string) (val interface{}, found bool, err error)
+	// look up the raw value by a top level key
+	lookupKey(key string) (val interface{}, found bool, err error)
+	convPolicy() ConvPolicy
+}
+
+//// generic getters, the typed methods of JsonMap and View are specializations of these
+
+// directly get value with type T
+func Get[T Scalar](d Getter, key string, def T) (val T, found bool, err error) {
+	raw, found, err := d.lookupKey(key)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = convert(d.convPolicy(), raw, def)
+	if err != nil {
+		err = withPath(err, []string{key})
+	}
+	return val, true, err
+}
+
+// recursively get value with type T
+func RGet[T Scalar](d Getter, keyPath []string, def T) (val T, found bool, err error) {
+	if len(keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	raw, found, err := d.lookup(keyPath)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = convert(d.convPolicy(), raw, def)
+	return val, true, withPath(err, keyPath)
+}
+
+// directly get slice with item type T
+func GetSlice[T Scalar](d Getter, key string, def []T) (val []T, found bool, err error) {
+	raw, found, err := d.lookupKey(key)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, idx, err := convertSlice[T](d.convPolicy(), raw)
+	if err != nil {
+		return def, true, withSliceIndex(err, []string{key}, idx)
+	}
+	return val, true, nil
+}
+
+// recursively get slice with item type T
+func RGetSlice[T Scalar](d Getter, keyPath []string, def []T) (val []T, found bool, err error) {
+	if len(keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	raw, found, err := d.lookup(keyPath)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, idx, err := convertSlice[T](d.convPolicy(), raw)
+	if err != nil {
+		return def, true, withSliceIndex(err, keyPath, idx)
+	}
+	return val, true, nil
+}
+
+//// generic type conversion
+
+// convert raw to T by toAny, will return def if failed
+func convert[T Scalar](p ConvPolicy, raw interface{}, def T) (T, error) {
+	v, err := p.toAny(raw, def)
+	if t, ok := v.(T); ok {
+		return t, err
+	}
+	// toAny returns the builtin type of def's kind, convert it to the named type
+	return reflect.ValueOf(v).Convert(reflect.TypeOf(def)).Interface().(T), err
+}
+
+// convert raw to []T item by item, idx is the index of the failed item or -1
+func convertSlice[T Scalar](p ConvPolicy, raw interface{}) (val []T, idx int, err error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, -1, typeError(raw, items)
+	}
+	var zero T
+	val = make([]T, 0, len(items))
+	for idx, i := range items {
+		v, e := convert(p, i, zero)
+		if e != nil {
+			return nil, idx, e
+		}
+		val = append(val, v)
+	}
+	return val, -1, nil
+}
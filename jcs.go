@@ -0,0 +1,212 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//// JSON Canonicalization Scheme (RFC 8785)
+
+// encode to canonical json by JCS: keys are sorted by UTF-16 code units, numbers are formatted as ECMAScript
+// double, strings are minimally escaped. Numbers are the same no matter they are decoded as float64,
+// json.Number or by smart numbers, so big integers are rounded to double as JCS requires.
+func (d JsonMap) Canonicalize() ([]byte, error) {
+	return canonicalize(map[string]interface{}(d))
+}
+
+// write canonical json of d to h, see Canonicalize
+func (d JsonMap) Hash(h hash.Hash) error {
+	return hashValue(map[string]interface{}(d), h)
+}
+
+// hex SHA-256 of canonical json, semantically equal maps have the same fingerprint
+func (d JsonMap) Fingerprint() (string, error) {
+	return fingerprint(map[string]interface{}(d))
+}
+
+func (v JsonValue) Canonicalize() ([]byte, error) {
+	return canonicalize(v.v)
+}
+
+func (v JsonValue) Hash(h hash.Hash) error {
+	return hashValue(v.v, h)
+}
+
+func (v JsonValue) Fingerprint() (string, error) {
+	return fingerprint(v.v)
+}
+
+func canonicalize(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hashValue(v interface{}, h hash.Hash) error {
+	b, err := canonicalize(v)
+	if err != nil {
+		return err
+	}
+	_, err = h.Write(b)
+	return err
+}
+
+func fingerprint(v interface{}) (string, error) {
+	h := sha256.New()
+	if err := hashValue(v, h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case string:
+		return writeCanonicalString(buf, t)
+	case float64:
+		return writeCanonicalNumber(buf, t)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			return fmt.Errorf("jsonmap: can't canonicalize number %s: %w", t, err)
+		}
+		return writeCanonicalNumber(buf, f)
+	case map[string]interface{}:
+		return writeCanonicalObject(buf, t)
+	case JsonMap:
+		return writeCanonicalObject(buf, t)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		// int64, uint64 by smart numbers, or other numbers set by users
+		if f, ok := queryNumber(v); ok {
+			return writeCanonicalNumber(buf, f)
+		}
+		return fmt.Errorf("jsonmap: can't canonicalize value of type %T", v)
+	}
+	return nil
+}
+
+func writeCanonicalObject(buf *bytes.Buffer, m map[string]interface{}) error {
+	type entry struct {
+		key   string
+		units []uint16
+	}
+	entries := make([]entry, 0, len(m))
+	for k := range m {
+		entries = append(entries, entry{k, utf16.Encode([]rune(k))})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].units, entries[j].units
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeCanonicalString(buf, e.key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := writeCanonical(buf, m[e.key]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// ECMAScript Number.prototype.toString
+func writeCanonicalNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("jsonmap: can't canonicalize number %v", f)
+	}
+	if f == 0 {
+		// no negative zero
+		buf.WriteByte('0')
+		return nil
+	}
+	// same as ECMAScript: shortest representation, exponent form if exponent < -6 or >= 21
+	buf.WriteString(formatFloat(f))
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("jsonmap: can't canonicalize invalid utf8 string %q", s)
+	}
+	const hexDigits = "0123456789abcdef"
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestCanonicalize(t *testing.T) {
+	// examples of RFC 8785
+	data := `{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/", "literals": [null, true, false]}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	for _, useNumber := range []bool{false, true} {
+		jm, _ := jsonmap.Unmarshal([]byte(data), useNumber)
+		if b, err := jm.Canonicalize(); err != nil || string(b) != expected {
+			t.Fatalf("Canonicalize failed: got (%s, %v)", b, err)
+		}
+	}
+
+	data = `{"€":"Euro Sign", "\r":"Carriage Return", "דּ":"Hebrew Letter Dalet With Dagesh",
+		"1":"One", "😀":"Emoji: Grinning Face", "\u0080":"Control", "ö":"Latin Small Letter O With Diaeresis"}`
+	expected = `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis",` +
+		`"€":"Euro Sign","😀":"Emoji: Grinning Face","דּ":"Hebrew Letter Dalet With Dagesh"}`
+	jm, _ := jsonmap.Unmarshal([]byte(data), false)
+	if b, err := jm.Canonicalize(); err != nil || string(b) != expected {
+		t.Fatalf("Canonicalize sorting failed: got (%s, %v)", b, err)
+	}
+
+	numbers := map[uint64]string{
+		0x0000000000000000: "0",
+		0x8000000000000000: "0",
+		0x0000000000000001: "5e-324",
+		0x8000000000000001: "-5e-324",
+		0x7fefffffffffffff: "1.7976931348623157e+308",
+		0x4340000000000000: "9007199254740992",
+		0x4430000000000000: "295147905179352830000",
+		0x44b52d02c7e14af5: "9.999999999999997e+22",
+		0x44b52d02c7e14af6: "1e+23",
+		0x3eb0c6f7a0b5ed8d: "0.000001",
+		0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+		0x41b3de4355555555: "333333333.3333333",
+	}
+	for bits, s := range numbers {
+		v := jsonmap.NewValue(math.Float64frombits(bits))
+		if b, err := v.Canonicalize(); err != nil || string(b) != s {
+			t.Fatalf("Canonicalize number %x failed: got (%s, %v), expect %s", bits, b, err, s)
+		}
+	}
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), "\xff"} {
+		if _, err := jsonmap.NewValue(v).Canonicalize(); err == nil {
+			t.Fatalf("Canonicalize %v should fail", v)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	data := `{"b":[1.0, 2e0, 7095620078347567873], "a":{"y":"é", "x":null}}`
+	jm1, _ := jsonmap.Unmarshal([]byte(data), false)
+	jm2, _ := jsonmap.Unmarshal([]byte(data), true)
+	jm3, _ := jsonmap.UnmarshalSmartNumbers([]byte(data))
+	jm4, _ := jsonmap.Unmarshal([]byte(`{"a":{"x":null,"y":"é"},"b":[1,2,7095620078347568000]}`), true)
+	f1, err := jm1.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	for _, jm := range []jsonmap.JsonMap{jm2, jm3, jm4} {
+		if f, err := jm.Fingerprint(); err != nil || f != f1 {
+			t.Fatalf("Fingerprint of %v failed: got (%s, %v), expect %s", jm, f, err, f1)
+		}
+	}
+	h := sha256.New()
+	if err := jm2.Hash(h); err != nil || hex.EncodeToString(h.Sum(nil)) != f1 {
+		t.Fatalf("Hash failed: got (%x, %v)", h.Sum(nil), err)
+	}
+	if f, _ := jm1.Value().Fingerprint(); f != f1 {
+		t.Fatalf("JsonValue.Fingerprint failed: got %s", f)
+	}
+	jm5, _ := jsonmap.Unmarshal([]byte(`{"a":{"x":null,"y":"é"},"b":[1,2,3]}`), true)
+	if f, _ := jm5.Fingerprint(); f == f1 {
+		t.Fatal("different maps should have different fingerprints")
+	}
+}
@@ -17,20 +17,62 @@ package jsonmap
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 )
 
 type JsonMap map[string]interface{}
 
+// compact json with sorted keys and without HTML escaping, see Marshal
 func (d JsonMap) String() string {
-	return fmt.Sprintf("JsonMap(%v)", map[string]interface{}(d))
+	return d.StringN(0)
+}
+
+// like String but truncated to at most n bytes followed by "...", n <= 0 means no truncation
+func (d JsonMap) StringN(n int) string {
+	b, err := d.Marshal(EscapeHTML(false))
+	if err != nil {
+		// e.g. NaN can't be marshalled
+		return fmt.Sprintf("JsonMap(%v)", map[string]interface{}(d))
+	}
+	return truncate(b, n)
 }
 
 func (d JsonMap) ToMap() map[string]interface{} {
 	return d
 }
 
+// bind a conversion policy, see ConvPolicy
+func (d JsonMap) WithPolicy(p ConvPolicy) View {
+	return newView(d, p)
+}
+
+// shortcut of WithPolicy(ConvPolicy{Coerce: true})
+func (d JsonMap) Coercing() View {
+	return newView(d, ConvPolicy{Coerce: true})
+}
+
+func (d JsonMap) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	if len(keyPath) == 0 {
+		return map[string]interface{}(d), true, nil
+	}
+	return d.rGet(keyPath, 0, nil, false)
+}
+
+func (d JsonMap) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	return rGetIn(map[string]interface{}(d), keyPath, 0, nil, true)
+}
+
+func (d JsonMap) lookupKey(key string) (val interface{}, found bool, err error) {
+	val, found = d[key]
+	return
+}
+
+func (d JsonMap) convPolicy() ConvPolicy {
+	return defaultConvPolicy
+}
+
 //// directly get, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
 
 // fetch origin value, no type assurance
@@ -45,6 +87,9 @@ func (d JsonMap) Get(key string, def interface{}) (val interface{}, found bool,
 func (d JsonMap) GetAny(key string, def interface{}) (val interface{}, found bool, err error) {
 	if raw, found := d[key]; found {
 		val, err = toAny(raw, def)
+		if err != nil {
+			err = withPath(err, []string{key})
+		}
 		return val, true, err
 	}
 	return def, false, nil
@@ -57,107 +102,128 @@ func (d JsonMap) GetSubMap(key string, def JsonMap) (val JsonMap, found bool, er
 	}
 	v, ok := raw.(map[string]interface{})
 	if !ok {
-		return def, true, fmt.Errorf("key %s type %T is not map", key, raw)
+		return def, true, notMapError([]string{key}, raw)
 	}
 	return JsonMap(v), true, nil
 }
 
 func (d JsonMap) GetString(key string, def string) (val string, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(string)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetBool(key string, def bool) (val bool, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(bool)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetFloat64(key string, def float64) (val float64, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(float64)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetFloat32(key string, def float32) (val float32, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(float32)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetInt64(key string, def int64) (val int64, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(int64)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetUint64(key string, def uint64) (val uint64, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(uint64)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetInt32(key string, def int32) (val int32, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(int32)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetUint32(key string, def uint32) (val uint32, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(uint32)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetInt(key string, def int) (val int, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(int)
-	return
+	return Get(d, key, def)
 }
 
 func (d JsonMap) GetUint(key string, def uint) (val uint, found bool, err error) {
-	raw, found, err := d.GetAny(key, def)
-	val = raw.(uint)
-	return
+	return Get(d, key, def)
 }
 
 //// recursively get, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+//// keyPath steps through arrays by index, negative index counts from the end, out of range means not found
 
-func (d JsonMap) rGet(keyPath []string, idx int, def interface{}) (val interface{}, found bool, err error) {
+func (d JsonMap) rGet(keyPath []string, idx int, def interface{}, strict bool) (val interface{}, found bool, err error) {
 	k := keyPath[idx]
 	v, found := d[k]
 	if !found {
 		return def, false, nil
 	}
-	if idx == len(keyPath)-1 {
+	return rGetIn(v, keyPath, idx+1, def, strict)
+}
+
+// step into v, which is the value at keyPath[0:idx], by keyPath[idx:].
+// v should be a map or an array, for array the key is used as index, see parseIndex for strict.
+func rGetIn(v interface{}, keyPath []string, idx int, def interface{}, strict bool) (val interface{}, found bool, err error) {
+	if idx == len(keyPath) {
 		return v, true, nil
 	}
-	vmp, ok := v.(map[string]interface{})
-	if !ok {
-		return def, false, fmt.Errorf("key %s type %T is not map", keyPath[0:idx+1], v)
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return JsonMap(vv).rGet(keyPath, idx, def, strict)
+	case []interface{}:
+		i, ok := parseIndex(keyPath[idx], strict)
+		if !ok {
+			return def, false, lookupError(keyPath, idx, v)
+		}
+		if i, ok := arrayIndex(i, len(vv)); ok {
+			return rGetIn(vv[i], keyPath, idx+1, def, strict)
+		}
+		return def, false, nil
+	}
+	return def, false, lookupError(keyPath, idx, v)
+}
+
+// parse key as array index. A strict index is the one of JSON Pointer: decimal digits without sign or leading zero,
+// and it's out of range of any array if it overflows int.
+func parseIndex(key string, strict bool) (int, bool) {
+	if !strict {
+		i, err := strconv.Atoi(key)
+		return i, err == nil
+	}
+	if !isPointerIndex(key) {
+		return 0, false
+	}
+	if i, err := strconv.Atoi(key); err == nil {
+		return i, true
 	}
-	return JsonMap(vmp).rGet(keyPath, idx+1, def)
+	return math.MaxInt, true
+}
+
+// negative index counts from the end of array, return false if out of range
+func arrayIndex(i, length int) (int, bool) {
+	if i < 0 {
+		i += length
+	}
+	return i, i >= 0 && i < length
 }
 
 // fetch origin value, no type assurance
 func (d JsonMap) RGet(keyPath []string, def interface{}) (val interface{}, found bool, err error) {
 	if len(keyPath) == 0 {
-		return def, false, fmt.Errorf("keyPath empty")
+		return def, false, emptyPathError()
 	}
-	return d.rGet(keyPath, 0, def)
+	return d.rGet(keyPath, 0, def, false)
 }
 
 // this method ensures val’s type is same as def
 func (d JsonMap) RGetAny(keyPath []string, def interface{}) (val interface{}, found bool, err error) {
 	if len(keyPath) == 0 {
-		return def, false, fmt.Errorf("keyPath empty")
+		return def, false, emptyPathError()
 	}
-	val, found, err = d.rGet(keyPath, 0, def)
+	val, found, err = d.rGet(keyPath, 0, def, false)
 	if !found || err != nil {
 		return
 	}
 	val, err = toAny(val, def)
+	err = withPath(err, keyPath)
 	return
 }
 
@@ -168,69 +234,49 @@ func (d JsonMap) RGetSubMap(keyPath []string, def JsonMap) (val JsonMap, found b
 	}
 	v, ok := raw.(map[string]interface{})
 	if !ok {
-		return def, true, fmt.Errorf("key %s type %T is not map", keyPath, raw)
+		return def, true, notMapError(keyPath, raw)
 	}
 	return JsonMap(v), true, nil
 }
 
 func (d JsonMap) RGetString(keyPath []string, def string) (val string, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(string)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetBool(keyPath []string, def bool) (val bool, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(bool)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetFloat64(keyPath []string, def float64) (val float64, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(float64)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetFloat32(keyPath []string, def float32) (val float32, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(float32)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetInt64(keyPath []string, def int64) (val int64, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(int64)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUint64(keyPath []string, def uint64) (val uint64, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(uint64)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetInt32(keyPath []string, def int32) (val int32, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(int32)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUint32(keyPath []string, def uint32) (val uint32, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(uint32)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetInt(keyPath []string, def int) (val int, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(int)
-	return
+	return RGet(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUint(keyPath []string, def uint) (val uint, found bool, err error) {
-	raw, found, err := d.RGetAny(keyPath, def)
-	val = raw.(uint)
-	return
+	return RGet(d, keyPath, def)
 }
 
 //// directly get slice, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
@@ -254,8 +300,7 @@ func (d JsonMap) GetAnySlice(key string, def []interface{}, itemType interface{}
 	for idx, i := range raw {
 		v, e := toAny(i, itemType)
 		if e != nil {
-			err = fmt.Errorf("key %s index %d: %v", key, idx, e)
-			return def, true, err
+			return def, true, withPath(e, []string{key}, strconv.Itoa(idx))
 		}
 		ret = append(ret, v)
 	}
@@ -263,123 +308,43 @@ func (d JsonMap) GetAnySlice(key string, def []interface{}, itemType interface{}
 }
 
 func (d JsonMap) GetStringSlice(key string, def []string) (val []string, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, string(""))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]string, 0)
-	for _, i := range raw {
-		val = append(val, i.(string))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetBoolSlice(key string, def []bool) (val []bool, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, bool(false))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]bool, 0)
-	for _, i := range raw {
-		val = append(val, i.(bool))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetFloat64Slice(key string, def []float64) (val []float64, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, float64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]float64, 0)
-	for _, i := range raw {
-		val = append(val, i.(float64))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetFloat32Slice(key string, def []float32) (val []float32, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, float32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]float32, 0)
-	for _, i := range raw {
-		val = append(val, i.(float32))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetInt64Slice(key string, def []int64) (val []int64, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, int64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int64, 0)
-	for _, i := range raw {
-		val = append(val, i.(int64))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetUint64Slice(key string, def []uint64) (val []uint64, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, uint64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint64, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint64))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetInt32Slice(key string, def []int32) (val []int32, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, int32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int32, 0)
-	for _, i := range raw {
-		val = append(val, i.(int32))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetUint32Slice(key string, def []uint32) (val []uint32, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, uint32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint32, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint32))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetIntSlice(key string, def []int) (val []int, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, int(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int, 0)
-	for _, i := range raw {
-		val = append(val, i.(int))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 func (d JsonMap) GetUintSlice(key string, def []uint) (val []uint, found bool, err error) {
-	raw, found, err := d.GetAnySlice(key, []interface{}{}, uint(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint))
-	}
-	return
+	return GetSlice(d, key, def)
 }
 
 //// recursively get slice, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
@@ -403,8 +368,7 @@ func (d JsonMap) RGetAnySlice(keyPath []string, def []interface{}, itemType inte
 	for idx, i := range raw {
 		v, e := toAny(i, itemType)
 		if e != nil {
-			err = fmt.Errorf("keyPath %s index %d: %v", keyPath, idx, e)
-			return def, true, err
+			return def, true, withIndex(e, keyPath, idx)
 		}
 		ret = append(ret, v)
 	}
@@ -412,130 +376,55 @@ func (d JsonMap) RGetAnySlice(keyPath []string, def []interface{}, itemType inte
 }
 
 func (d JsonMap) RGetStringSlice(keyPath []string, def []string) (val []string, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, string(""))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]string, 0)
-	for _, i := range raw {
-		val = append(val, i.(string))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetBoolSlice(keyPath []string, def []bool) (val []bool, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, bool(false))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]bool, 0)
-	for _, i := range raw {
-		val = append(val, i.(bool))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetFloat64Slice(keyPath []string, def []float64) (val []float64, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, float64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]float64, 0)
-	for _, i := range raw {
-		val = append(val, i.(float64))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetFloat32Slice(keyPath []string, def []float32) (val []float32, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, float32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]float32, 0)
-	for _, i := range raw {
-		val = append(val, i.(float32))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetInt64Slice(keyPath []string, def []int64) (val []int64, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, int64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int64, 0)
-	for _, i := range raw {
-		val = append(val, i.(int64))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUint64Slice(keyPath []string, def []uint64) (val []uint64, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, uint64(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint64, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint64))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetInt32Slice(keyPath []string, def []int32) (val []int32, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, int32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int32, 0)
-	for _, i := range raw {
-		val = append(val, i.(int32))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUint32Slice(keyPath []string, def []uint32) (val []uint32, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, uint32(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint32, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint32))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetIntSlice(keyPath []string, def []int) (val []int, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, int(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]int, 0)
-	for _, i := range raw {
-		val = append(val, i.(int))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 func (d JsonMap) RGetUintSlice(keyPath []string, def []uint) (val []uint, found bool, err error) {
-	raw, found, err := d.RGetAnySlice(keyPath, []interface{}{}, uint(0))
-	if !found || err != nil {
-		return def, found, err
-	}
-	val = make([]uint, 0)
-	for _, i := range raw {
-		val = append(val, i.(uint))
-	}
-	return
+	return RGetSlice(d, keyPath, def)
 }
 
 //// type conversion
 
+// convert raw to same type as def by the default policy, will return def if failed
+func toAny(raw, def interface{}) (val interface{}, err error) {
+	return defaultConvPolicy.toAny(raw, def)
+}
+
 // convert raw to same type as def, will return def if failed
 // if def is number, only float64, float32, int64, uint64, int32, uint32, int, uint are supported
-func toAny(raw, def interface{}) (val interface{}, err error) {
+func (p ConvPolicy) toAny(raw, def interface{}) (val interface{}, err error) {
 	if def == nil && raw == nil {
 		return raw, nil
 	}
@@ -544,50 +433,248 @@ func toAny(raw, def interface{}) (val interface{}, err error) {
 		dtk := reflect.TypeOf(def).Kind()
 		if f, ok := raw.(float64); ok {
 			// NOTICE: json unmarshal all numbers to float64 as default, maybe precision lost
-			if dtk == reflect.Float64 {
-				return raw, nil
-			} else if dtk == reflect.Float32 {
-				return float32(f), nil
-			} else if dtk == reflect.Int64 {
-				return int64(f), nil
-			} else if dtk == reflect.Uint64 {
-				return uint64(f), nil
-			} else if dtk == reflect.Int32 {
-				return int32(f), nil
-			} else if dtk == reflect.Uint32 {
-				return uint32(f), nil
-			} else if dtk == reflect.Int {
-				return int(f), nil
-			} else if dtk == reflect.Uint {
-				return uint(f), nil
+			if isNumberKind(dtk) {
+				return p.floatToAny(f, raw, def, dtk)
 			}
 		} else if n, ok := raw.(json.Number); ok {
-			// NOTICE: if err != nil, val is not def, use strconv.Parsexxx returned
-			if dtk == reflect.Float64 {
-				return n.Float64()
-			} else if dtk == reflect.Float32 {
-				v, e := strconv.ParseFloat(string(n), 32)
-				return float32(v), e
-			} else if dtk == reflect.Int64 {
-				return n.Int64()
-			} else if dtk == reflect.Uint64 {
-				return strconv.ParseUint(string(n), 10, 64)
-			} else if dtk == reflect.Int32 {
-				v, e := strconv.ParseInt(string(n), 10, 32)
-				return int32(v), e
-			} else if dtk == reflect.Uint32 {
-				v, e := strconv.ParseUint(string(n), 10, 32)
-				return uint32(v), e
-			} else if dtk == reflect.Int {
-				v, e := strconv.ParseInt(string(n), 10, int(strconv.IntSize))
-				return int(v), e
-			} else if dtk == reflect.Uint {
-				v, e := strconv.ParseUint(string(n), 10, int(strconv.IntSize))
-				return uint(v), e
+			if isNumberKind(dtk) {
+				return p.numberToAny(n, raw, def, dtk)
 			}
+		} else if i, ok := raw.(int64); ok && isNumberKind(dtk) {
+			// decoded by UnmarshalSmartNumbers
+			return int64ToAny(i, raw, def, dtk)
+		} else if u, ok := raw.(uint64); ok && isNumberKind(dtk) {
+			return uint64ToAny(u, raw, def, dtk)
 		} else if rtk == dtk {
 			return raw, nil
 		}
+		if p.Coerce {
+			return p.coerce(raw, def, dtk)
+		}
+	}
+	return def, typeError(raw, def)
+}
+
+// convert float number to number of kind k, raw is the original value of f
+func (p ConvPolicy) floatToAny(f float64, raw, def interface{}, k reflect.Kind) (val interface{}, err error) {
+	if isIntegerKind(k) && f != math.Trunc(f) && !math.IsNaN(f) {
+		switch p.Fraction {
+		case RejectFraction:
+			return def, fractionError(raw, def)
+		case Round:
+			f = math.Round(f)
+		}
+	}
+	if v, ok := floatToKind(f, k); ok {
+		return v, nil
+	}
+	return def, overflowError(raw, def)
+}
+
+// convert json.Number to number of kind k, integers are parsed exactly,
+// and other numbers are parsed as float64 then converted the same as useNumber=false.
+// raw is the original value of n, it may be a json.Number or a string if coerced
+func (p ConvPolicy) numberToAny(n json.Number, raw, def interface{}, k reflect.Kind) (val interface{}, err error) {
+	var e error
+	switch k {
+	case reflect.Float64:
+		var v float64
+		if v, e = strconv.ParseFloat(string(n), 64); e == nil {
+			return v, nil
+		}
+	case reflect.Float32:
+		var v float64
+		if v, e = strconv.ParseFloat(string(n), 32); e == nil {
+			return float32(v), nil
+		}
+	case reflect.Int64, reflect.Int32, reflect.Int:
+		var v int64
+		if v, e = strconv.ParseInt(string(n), 10, kindBits(k)); e == nil {
+			return intToKind(v, k), nil
+		}
+	case reflect.Uint64, reflect.Uint32, reflect.Uint:
+		var v uint64
+		if v, e = strconv.ParseUint(string(n), 10, kindBits(k)); e == nil {
+			return uintToKind(v, k), nil
+		}
+	}
+	if isIntegerKind(k) && e.(*strconv.NumError).Err == strconv.ErrSyntax {
+		// fractional, exponent or negative number for unsigned types
+		if f, fe := strconv.ParseFloat(string(n), 64); fe == nil {
+			return p.floatToAny(f, raw, def, k)
+		}
+	}
+	return def, numberError(raw, def, e)
+}
+
+// convert integer to number of kind k exactly, raw is the original value of i
+func int64ToAny(i int64, raw, def interface{}, k reflect.Kind) (val interface{}, err error) {
+	switch k {
+	case reflect.Float64:
+		return float64(i), nil
+	case reflect.Float32:
+		return float32(i), nil
+	case reflect.Int64, reflect.Int32, reflect.Int:
+		if bits := kindBits(k); bits == 64 || (i >= -1<<(bits-1) && i < 1<<(bits-1)) {
+			return intToKind(i, k), nil
+		}
+	default:
+		if i >= 0 {
+			return uint64ToAny(uint64(i), raw, def, k)
+		}
+	}
+	return def, overflowError(raw, def)
+}
+
+// convert unsigned integer to number of kind k exactly, raw is the original value of u
+func uint64ToAny(u uint64, raw, def interface{}, k reflect.Kind) (val interface{}, err error) {
+	switch k {
+	case reflect.Float64:
+		return float64(u), nil
+	case reflect.Float32:
+		return float32(u), nil
+	case reflect.Uint64, reflect.Uint32, reflect.Uint:
+		if bits := kindBits(k); bits == 64 || u>>uint(bits) == 0 {
+			return uintToKind(u, k), nil
+		}
+	default:
+		if u>>uint(kindBits(k)-1) == 0 {
+			return intToKind(int64(u), k), nil
+		}
+	}
+	return def, overflowError(raw, def)
+}
+
+// lenient conversion between strings and numbers or bools, it's used if Coerce is set
+func (p ConvPolicy) coerce(raw, def interface{}, k reflect.Kind) (val interface{}, err error) {
+	switch r := raw.(type) {
+	case string:
+		if isNumberKind(k) && isNumberLiteral(r) {
+			return p.numberToAny(json.Number(r), raw, def, k)
+		}
+		if k == reflect.Bool {
+			if b, e := strconv.ParseBool(r); e == nil {
+				return b, nil
+			}
+		}
+	case float64:
+		if k == reflect.String {
+			return formatFloat(r), nil
+		}
+	case json.Number:
+		if k == reflect.String {
+			return string(r), nil
+		}
+	case int64:
+		if k == reflect.String {
+			return strconv.FormatInt(r, 10), nil
+		}
+	case uint64:
+		if k == reflect.String {
+			return strconv.FormatUint(r, 10), nil
+		}
+	case bool:
+		if k == reflect.String {
+			return strconv.FormatBool(r), nil
+		}
+	}
+	return def, typeError(raw, def)
+}
+
+// s is a number in json syntax, e.g. "-1", "1.5e3", but not " 1", "0x10" or "NaN"
+func isNumberLiteral(s string) bool {
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || s[len(s)-1] < '0' || s[len(s)-1] > '9' {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+// format float number the same as encoding/json, the shortest representation that keeps precision
+func formatFloat(f float64) string {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k == reflect.Float64 || k == reflect.Float32 || isIntegerKind(k)
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int64, reflect.Uint64, reflect.Int32, reflect.Uint32, reflect.Int, reflect.Uint:
+		return true
+	}
+	return false
+}
+
+func kindBits(k reflect.Kind) int {
+	switch k {
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 32
+	case reflect.Int, reflect.Uint:
+		return strconv.IntSize
+	}
+	return 64
+}
+
+func intToKind(v int64, k reflect.Kind) interface{} {
+	switch k {
+	case reflect.Int32:
+		return int32(v)
+	case reflect.Int:
+		return int(v)
+	}
+	return v
+}
+
+func uintToKind(v uint64, k reflect.Kind) interface{} {
+	switch k {
+	case reflect.Uint32:
+		return uint32(v)
+	case reflect.Uint:
+		return uint(v)
+	}
+	return v
+}
+
+// convert f to number of kind k, ok is false if f is NaN or Inf or out of range of k after truncated.
+// for float32, it's only out of range if f is finite but exceeds float32's range.
+func floatToKind(f float64, k reflect.Kind) (val interface{}, ok bool) {
+	if k == reflect.Float32 {
+		return float32(f), math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) <= math.MaxFloat32
+	}
+	if k == reflect.Float64 {
+		return f, true
+	}
+	t := math.Trunc(f)
+	// ranges are [lower, upper), NaN is out of any range
+	inRange := func(lower, upper float64) bool {
+		return t >= lower && t < upper
+	}
+	switch k {
+	case reflect.Int64:
+		return int64(t), inRange(-1<<63, 1<<63)
+	case reflect.Uint64:
+		return uint64(t), inRange(0, 1<<64)
+	case reflect.Int32:
+		return int32(t), inRange(-1<<31, 1<<31)
+	case reflect.Uint32:
+		return uint32(t), inRange(0, 1<<32)
+	case reflect.Int:
+		return int(t), inRange(-1<<(strconv.IntSize-1), 1<<(strconv.IntSize-1))
+	case reflect.Uint:
+		return uint(t), inRange(0, 1<<strconv.IntSize)
 	}
-	return def, fmt.Errorf("type error: got %T but expected %T", raw, def)
+	return nil, false
 }
@@ -15,7 +15,12 @@
 package jsonmap_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/peacalm/go-jsonmap"
@@ -69,7 +74,7 @@ func TestGet(t *testing.T) {
 		fmt.Println("GetInt from float number 1.3, with useNumber=true: ", v, f, e)
 	}
 	testGetInt(t, jm1, "f", 0, int(f), true, false) // NOTICE: no error, but precision lost
-	testGetInt(t, jm2, "f", 0, 0, true, true)       // NOTICE: error, return 0
+	testGetInt(t, jm2, "f", 0, int(f), true, false) // NOTICE: same as useNumber=false
 	testGet(t, jm1, jm2, "b1", -1, -1, true, true)
 
 	// 64bit long int
@@ -152,6 +157,177 @@ func TestRGet(t *testing.T) {
 	}
 }
 
+func TestRGetArray(t *testing.T) {
+	data := `{"servers":[{"port":80},{"port":443,"tags":["a","b"]}], "m":{"0":"zero"}}`
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(data), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v, string = %v", err, useNumber, data)
+		}
+		testRGetInt(t, jm, []string{"servers", "0", "port"}, 0, 80, true, false)
+		testRGetInt(t, jm, []string{"servers", "1", "port"}, 0, 443, true, false)
+		testRGetInt(t, jm, []string{"servers", "-1", "port"}, 0, 443, true, false)
+		testRGetInt(t, jm, []string{"servers", "-2", "port"}, 0, 80, true, false)
+		testRGetInt(t, jm, []string{"servers", "2", "port"}, -1, -1, false, false)
+		testRGetInt(t, jm, []string{"servers", "-3", "port"}, -1, -1, false, false)
+		testRGetInt(t, jm, []string{"servers", "x", "port"}, -1, -1, false, true)
+		testRGetInt(t, jm, []string{"servers", "0", "port", "x"}, -1, -1, false, true)
+		if v, f, e := jm.RGetString([]string{"servers", "1", "tags", "-1"}, ""); v != "b" || !f || e != nil {
+			t.Fatalf("RGetString failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.RGetString([]string{"m", "0"}, ""); v != "zero" || !f || e != nil {
+			t.Fatalf("RGetString failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.RGetSubMap([]string{"servers", "1"}, nil); len(v) != 2 || !f || e != nil {
+			t.Fatalf("RGetSubMap failed: got (%v, %v, %v)", v, f, e)
+		}
+	}
+}
+
+func testRGetInt(t *testing.T, jm jsonmap.JsonMap, keyPath []string, def, expectedVal int, keyExists, hasErr bool) {
+	if v, f, e := jm.RGetInt(keyPath, def); v != expectedVal || f != keyExists || bool(e != nil) != hasErr {
+		t.Fatalf("RGetInt failed: got (%v, %v, %v), expect = (%v, %v, hasErr:%v), keyPath = %v, jm = %v", v, f, e, expectedVal, keyExists, hasErr, keyPath, jm)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	data := `{"a":{"b":[1,"x"]}, "s":"str", "n":1.5, "big":100000000000}`
+	jm1, _ := jsonmap.Unmarshal([]byte(data), false)
+	jm2, _ := jsonmap.Unmarshal([]byte(data), true)
+	cases := []struct {
+		get      func(jm jsonmap.JsonMap) error
+		sentinel error
+		path     []string
+		index    int
+	}{
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.GetInt("s", 0); return e }, jsonmap.ErrTypeMismatch, []string{"s"}, 0},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.GetSubMap("s", nil); return e }, jsonmap.ErrNotMap, []string{"s"}, 0},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.RGetInt(nil, 0); return e }, jsonmap.ErrEmptyPath, nil, -1},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.RGetInt([]string{"s", "x", "y"}, 0); return e }, jsonmap.ErrNotMap, []string{"s", "x", "y"}, 0},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.RGetInt([]string{"a", "b", "x"}, 0); return e }, jsonmap.ErrInvalidIndex, []string{"a", "b", "x"}, 1},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.RGetIntSlice([]string{"a", "b"}, nil); return e }, jsonmap.ErrTypeMismatch, []string{"a", "b", "1"}, 2},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.GetIntSlice("s", nil); return e }, jsonmap.ErrTypeMismatch, []string{"s"}, 0},
+		{func(jm jsonmap.JsonMap) error { _, _, e := jm.PGetSubMap("/a/b", nil); return e }, jsonmap.ErrNotMap, []string{"a", "b"}, 1},
+	}
+	for _, jm := range []jsonmap.JsonMap{jm1, jm2} {
+		for i, c := range cases {
+			err := c.get(jm)
+			var pe *jsonmap.PathError
+			if !errors.Is(err, c.sentinel) || !errors.As(err, &pe) || !reflect.DeepEqual(pe.Path, c.path) || pe.Index != c.index {
+				t.Fatalf("case %d failed: got error %v, %#v", i, err, pe)
+			}
+		}
+	}
+
+	// strconv errors are wrapped when useNumber=true
+	_, _, err := jsonmap.JsonMap{"n": json.Number("x")}.GetInt("n", 0)
+	var ne *strconv.NumError
+	if !errors.Is(err, jsonmap.ErrTypeMismatch) || !errors.As(err, &ne) || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("GetInt by invalid json.Number failed: got error %v", err)
+	}
+	_, _, err = jm2.GetInt32("big", 0)
+	if !errors.Is(err, jsonmap.ErrOverflow) || !errors.As(err, &ne) {
+		t.Fatalf("GetInt32 by 100000000000 with useNumber=true failed: got error %v", err)
+	}
+	_, _, err = jm1.RGetString([]string{"a", "b", "0"}, "")
+	if err == nil || err.Error() != `jsonmap: key "/a/b/0": type mismatch: got float64 but expected string` {
+		t.Fatalf("RGetString failed: got error %v", err)
+	}
+	_, _, err = jm1.RGetString([]string{"a", "b", "0", "x"}, "")
+	if err == nil || err.Error() != `jsonmap: key "/a/b/0/x": not map at "/a/b/0": got float64 but expected map or array` {
+		t.Fatalf("RGetString failed: got error %v", err)
+	}
+}
+
+func TestSmartNumbers(t *testing.T) {
+	data := `{"longint":7095620078347567873, "maxu":18446744073709551615, "neg":-1, "f":1.5, "e":1e3,
+		"beyond":18446744073709551616, "a":[9007199254740993]}`
+	jm, err := jsonmap.UnmarshalSmartNumbers([]byte(data))
+	if err != nil {
+		t.Fatalf("UnmarshalSmartNumbers failed: %v", err)
+	}
+	if v, f, e := jm.GetInt64("longint", 0); v != 7095620078347567873 || !f || e != nil {
+		t.Fatalf("GetInt64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetUint64("maxu", 0); v != math.MaxUint64 || !f || e != nil {
+		t.Fatalf("GetUint64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetInt64("maxu", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+		t.Fatalf("GetInt64 by max uint64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetInt32("longint", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+		t.Fatalf("GetInt32 by big int failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetUint("neg", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+		t.Fatalf("GetUint by -1 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetInt32("neg", 7); v != -1 || !f || e != nil {
+		t.Fatalf("GetInt32 by -1 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetFloat64("neg", 7); v != -1 || !f || e != nil {
+		t.Fatalf("GetFloat64 by -1 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetInt("f", 7); v != 1 || !f || e != nil {
+		t.Fatalf("GetInt by 1.5 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetInt("e", 7); v != 1000 || !f || e != nil {
+		t.Fatalf("GetInt by 1e3 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.RGetInt64([]string{"a", "0"}, 0); v != 9007199254740993 || !f || e != nil {
+		t.Fatalf("RGetInt64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.Coercing().GetString("longint", ""); v != "7095620078347567873" || !f || e != nil {
+		t.Fatalf("Coercing GetString failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("beyond", nil); v == nil || v.String() != "18446744073709551616" || !f || e != nil {
+		t.Fatalf("GetBigInt beyond uint64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm.GetBigInt("maxu", nil); v == nil || v.String() != "18446744073709551615" || !f || e != nil {
+		t.Fatalf("GetBigInt by max uint64 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, _ := jm.QueryInt64s("$.a[?(@ > 9007199254740000)]"); len(v) != 1 {
+		t.Fatalf("Query smart numbers failed: got %v", v)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	data := `{"neg":-1, "big":1e12, "huge":1e300, "max64":9223372036854775807, "frac":-0.5}`
+	jm1, _ := jsonmap.Unmarshal([]byte(data), false)
+	jm2, _ := jsonmap.Unmarshal([]byte(data), true)
+	for _, jm := range []jsonmap.JsonMap{jm1, jm2} {
+		if v, f, e := jm.GetUint("neg", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+			t.Fatalf("GetUint by -1 failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.GetUint32("neg", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+			t.Fatalf("GetUint32 by -1 failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.GetFloat32("huge", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+			t.Fatalf("GetFloat32 by 1e300 failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.GetInt64("big", 7); v != 1e12 || !f || e != nil {
+			t.Fatalf("GetInt64 by 1e12 failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.GetInt32("big", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+			t.Fatalf("GetInt32 by 1e12 failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.GetUint("frac", 7); v != 0 || !f || e != nil {
+			t.Fatalf("GetUint by -0.5 failed: got (%v, %v, %v)", v, f, e)
+		}
+	}
+	if v, f, e := jm1.GetInt64("max64", 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+		t.Fatalf("GetInt64 by 2^63 failed: got (%v, %v, %v)", v, f, e)
+	}
+	if v, f, e := jm1.GetUint64("max64", 7); v != 1<<63 || !f || e != nil {
+		t.Fatalf("GetUint64 by 2^63 failed: got (%v, %v, %v)", v, f, e)
+	}
+	jm3 := jsonmap.JsonMap{"nan": math.NaN(), "inf": math.Inf(-1)}
+	for _, key := range []string{"nan", "inf"} {
+		if v, f, e := jm3.GetInt(key, 7); v != 7 || !f || !errors.Is(e, jsonmap.ErrOverflow) {
+			t.Fatalf("GetInt by %s failed: got (%v, %v, %v)", key, v, f, e)
+		}
+	}
+}
+
 const rep = 1000000
 const jsonStrForPerfTest = `{"a":{"b":{"i":1234567890,"b":true,"s":"str"}},"i":1234567890,"b":true,"s":"str"}`
 
@@ -204,3 +380,40 @@ func Test_Performance_GetString_UseNumber(t *testing.T) {
 		_, _, _ = jm.GetString("s", "")
 	}
 }
+
+type port uint32
+type name string
+
+func TestGeneric(t *testing.T) {
+	data := `{"port":8080, "name":"svc", "f":1.5, "sub":{"ports":[80,443]}, "mixed":[1,"x"]}`
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(data), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v, string = %v", err, useNumber, data)
+		}
+		if v, f, e := jsonmap.Get(jm, "port", port(0)); v != 8080 || !f || e != nil {
+			t.Fatalf("Get[port] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.Get(jm, "name", name("def")); v != "svc" || !f || e != nil {
+			t.Fatalf("Get[name] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.Get(jm, "name", port(1)); v != 1 || !f || e == nil {
+			t.Fatalf("Get[port] by string failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.Get(jm, "none", float32(-1)); v != -1 || f || e != nil {
+			t.Fatalf("Get[float32] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.RGet(jm, []string{"sub", "none"}, port(1)); v != 1 || f || e != nil {
+			t.Fatalf("RGet[port] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.RGetSlice(jm, []string{"sub", "ports"}, []port{}); len(v) != 2 || v[1] != 443 || !f || e != nil {
+			t.Fatalf("RGetSlice[port] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.GetSlice(jm, "mixed", []int(nil)); v != nil || !f || e == nil {
+			t.Fatalf("GetSlice[int] failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+		if v, f, e := jsonmap.GetSlice(jm, "port", []int{1}); len(v) != 1 || !f || e == nil {
+			t.Fatalf("GetSlice[int] by number failed: got (%v, %v, %v), useNumber = %v", v, f, e, useNumber)
+		}
+	}
+}
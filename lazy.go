@@ -0,0 +1,451 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// LazyJsonMap is a json object kept as raw bytes, values are located by scanning the bytes on demand,
+// so reading a few fields of a large document is much cheaper than Unmarshal.
+// It has the same getters as JsonMap, decoded objects and arrays are memoized.
+//
+// Same as Unmarshal, if an object has the same key twice, the last one is used.
+// It's safe for concurrent use.
+type LazyJsonMap struct {
+	getters
+}
+
+// data is validated but not decoded, it must not be modified after.
+// Options UseNumber, SmartNumbers and WithConvPolicy decide how values are decoded and converted,
+// MaxBytes is checked on data, DisallowDuplicateKeys and ReportDuplicateKeys check the whole data here.
+// Other limits apply to values read and to objects and arrays on the way of their key paths,
+// with depth, key paths and offsets counted in the whole data the same as UnmarshalWithOptions.
+func NewLazyJsonMap(data []byte, opts ...Option) (LazyJsonMap, error) {
+	src := &lazySource{opts: *newDecodeOptions(opts), memo: make(map[string]interface{})}
+	start, end, err := src.opts.scanObject(data)
+	if err != nil {
+		return LazyJsonMap{}, err
+	}
+	src.data, src.start, src.end = data, start, end
+	if src.opts.checkDuplicates() {
+		if err := src.opts.scanDuplicates(data, start, nil); err != nil {
+			return LazyJsonMap{}, err
+		}
+		// no need to check again when values are decoded
+		src.opts.disallowDuplicateKeys, src.opts.duplicates = false, nil
+	}
+	return LazyJsonMap{getters{src}}, nil
+}
+
+// raw bytes of the object
+func (d LazyJsonMap) Bytes() []byte {
+	if src, ok := d.src.(*lazySource); ok {
+		return src.data[src.start:src.end]
+	}
+	return nil
+}
+
+// raw bytes of the value at keyPath, an empty keyPath means the whole object
+func (d LazyJsonMap) RawAt(keyPath ...string) (raw []byte, found bool, err error) {
+	src, ok := d.src.(*lazySource)
+	if !ok {
+		return nil, false, nil
+	}
+	start, end, found, err := src.find(keyPath, false)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	return src.data[start:end], true, nil
+}
+
+// decode the whole object
+func (d LazyJsonMap) Map() JsonMap {
+	m, _, _ := d.lookup(nil)
+	jm, _ := m.(map[string]interface{})
+	return jm
+}
+
+func (d LazyJsonMap) GetSubMap(key string, def JsonMap) (val JsonMap, found bool, err error) {
+	return d.RGetSubMap([]string{key}, def)
+}
+
+func (d LazyJsonMap) RGetSubMap(keyPath []string, def JsonMap) (val JsonMap, found bool, err error) {
+	v, found, err := d.RGet(keyPath, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true, nil
+	}
+	return def, true, notMapError(keyPath, v)
+}
+
+//// lazySource is the Getter of LazyJsonMap
+
+type lazySource struct {
+	data       []byte // the whole input
+	start, end int    // bounds of the top level object
+	opts       decodeOptions
+	mu         sync.Mutex
+	memo       map[string]interface{} // decoded objects and arrays by json pointer
+}
+
+func (s *lazySource) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	return s.lookupIn(keyPath, false)
+}
+
+func (s *lazySource) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	return s.lookupIn(keyPath, true)
+}
+
+func (s *lazySource) lookupIn(keyPath []string, strict bool) (val interface{}, found bool, err error) {
+	start, end, found, err := s.find(keyPath, strict)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	val, err = s.decode(keyPath, start, end)
+	return val, true, err
+}
+
+func (s *lazySource) lookupKey(key string) (val interface{}, found bool, err error) {
+	return s.lookup([]string{key})
+}
+
+func (s *lazySource) convPolicy() ConvPolicy {
+	return s.opts.policy
+}
+
+// find bounds of the value at keyPath in data, errors are the same as JsonMap.RGet,
+// and limits are checked on objects and arrays on the way. See parseIndex for strict.
+func (s *lazySource) find(keyPath []string, strict bool) (start, end int, found bool, err error) {
+	start, end = s.start, s.end
+	for idx, key := range keyPath {
+		var i, j int
+		switch s.data[start] {
+		case '{':
+			i, j, found, err = s.opts.findKey(s.data, start, end, keyPath[:idx], key)
+		case '[':
+			n, ok := parseIndex(key, strict)
+			if !ok {
+				return 0, 0, false, lookupError(keyPath, idx, []interface{}(nil))
+			}
+			i, j, found, err = s.opts.findIndex(s.data, start, end, keyPath[:idx], n)
+		default:
+			v, e := s.decode(keyPath[:idx], start, end)
+			if e != nil {
+				return 0, 0, false, e
+			}
+			return 0, 0, false, lookupError(keyPath, idx, v)
+		}
+		if !found || err != nil {
+			return 0, 0, false, err
+		}
+		start, end = start+i, start+j
+	}
+	return start, end, true, nil
+}
+
+// decode value data[start:end] at keyPath, objects and arrays are memoized
+func (s *lazySource) decode(keyPath []string, start, end int) (interface{}, error) {
+	raw := s.data[start:end]
+	switch raw[0] {
+	case '{', '[':
+	case '"':
+		if s.opts.maxStringLen > 0 && len(raw)-2 > s.opts.maxStringLen {
+			return nil, &LimitError{Limit: "MaxStringLen", Max: s.opts.maxStringLen, Path: copyKeyPath(keyPath), Offset: int64(start)}
+		}
+		if !hasEscape(raw) {
+			return string(raw[1 : len(raw)-1]), nil
+		}
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case 't':
+		return true, nil
+	case 'f':
+		return false, nil
+	case 'n':
+		return nil, nil
+	default:
+		return s.opts.number(json.Number(raw))
+	}
+	key := FormatPointer(keyPath)
+	s.mu.Lock()
+	v, ok := s.memo[key]
+	s.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	v, err := s.opts.unmarshalAt(s.data, start, end, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.memo[key] = v
+	s.mu.Unlock()
+	return v, nil
+}
+
+// MaxDepth of the object or array data[start:end] at keyPath
+func (o *decodeOptions) checkDepth(start int, keyPath []string) error {
+	if o.maxDepth > 0 && len(keyPath) >= o.maxDepth {
+		return &LimitError{Limit: "MaxDepth", Max: o.maxDepth, Path: copyKeyPath(keyPath), Offset: int64(start + 1)}
+	}
+	return nil
+}
+
+// find the value of key in object data[start:end] at keyPath, bounds are relative to start.
+// The last one is used if the key is duplicate, same as Unmarshal. MaxKeys and MaxStringLen are checked on all keys.
+func (o *decodeOptions) findKey(data []byte, start, end int, keyPath []string, key string) (i, j int, found bool, err error) {
+	if err = o.checkDepth(start, keyPath); err != nil {
+		return 0, 0, false, err
+	}
+	if !o.limited() {
+		i, j, found = findKey(data[start:end], key)
+		return i, j, found, nil
+	}
+	// keys only used to count keys
+	var keys map[string]bool
+	if o.maxKeys > 0 {
+		keys = make(map[string]bool)
+	}
+	prevEnd := start // end of the previous member, where the decoder stops before reading a key
+	eachMember(data[start:end], func(quotedKey []byte, ks, s, e int) bool {
+		if o.maxKeys > 0 && len(keys) >= o.maxKeys {
+			err = &LimitError{Limit: "MaxKeys", Max: o.maxKeys, Path: copyKeyPath(keyPath), Offset: int64(prevEnd)}
+			return false
+		}
+		prevEnd = start + e
+		if o.maxStringLen > 0 && len(quotedKey)-2 > o.maxStringLen {
+			err = &LimitError{Limit: "MaxStringLen", Max: o.maxStringLen, Path: copyKeyPath(keyPath), Offset: int64(start + ks)}
+			return false
+		}
+		if keys != nil {
+			k := string(quotedKey[1 : len(quotedKey)-1])
+			if hasEscape(quotedKey) {
+				k, _ = decodeKey(quotedKey)
+			}
+			keys[k] = true
+		}
+		if keyEquals(quotedKey, key) {
+			i, j, found = s, e, true
+		}
+		return true
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return i, j, found, nil
+}
+
+// find the item at index idx of array data[start:end] at keyPath, bounds are relative to start.
+// Negative index counts from the end. MaxArrayLen is checked on the array.
+func (o *decodeOptions) findIndex(data []byte, start, end int, keyPath []string, idx int) (i, j int, found bool, err error) {
+	if err = o.checkDepth(start, keyPath); err != nil {
+		return 0, 0, false, err
+	}
+	if o.maxArrayLen <= 0 {
+		i, j, found = findIndex(data[start:end], idx)
+		return i, j, found, nil
+	}
+	n, prevEnd := 0, start // end of the previous item, where the decoder stops before reading an item
+	eachItem(data[start:end], func(s, e int) bool {
+		if n >= o.maxArrayLen {
+			err = &LimitError{Limit: "MaxArrayLen", Max: o.maxArrayLen, Path: copyKeyPath(keyPath), Offset: int64(prevEnd)}
+			return false
+		}
+		n, prevEnd = n+1, start+e
+		return true
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	i, j, found = findIndex(data[start:end], idx)
+	return i, j, found, nil
+}
+
+//// scanner of valid json
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\r' || data[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// end of the value starting at data[i]
+func skipValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(data); j++ {
+			switch data[j] {
+			case '"':
+				j = skipString(data, j) - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return j + 1
+				}
+			}
+		}
+		return len(data)
+	}
+	j := i
+	for j < len(data) && data[j] != ',' && data[j] != '}' && data[j] != ']' &&
+		data[j] != ' ' && data[j] != '\t' && data[j] != '\r' && data[j] != '\n' {
+		j++
+	}
+	return j
+}
+
+// end of the string starting at data[i], which is the opening quote
+func skipString(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(data)
+}
+
+func hasEscape(quoted []byte) bool {
+	for _, c := range quoted {
+		if c == '\\' {
+			return true
+		}
+	}
+	return false
+}
+
+// check duplicate keys of all objects in the value starting at data[i], which is at keyPath
+func (o *decodeOptions) scanDuplicates(data []byte, i int, keyPath []string) (err error) {
+	end := skipValue(data, i)
+	switch data[i] {
+	case '{':
+		offsets := make(map[string]int64)
+		eachMember(data[i:end], func(quotedKey []byte, ks, s, e int) bool {
+			key := string(quotedKey[1 : len(quotedKey)-1])
+			if hasEscape(quotedKey) {
+				key, _ = decodeKey(quotedKey)
+			}
+			offset := int64(i + ks)
+			if first, ok := offsets[key]; !ok {
+				offsets[key] = offset
+			} else if err = o.duplicate(DuplicateKey{Path: append(copyKeyPath(keyPath), key), FirstOffset: first, Offset: offset}); err != nil {
+				return false
+			}
+			err = o.scanDuplicates(data, i+s, append(keyPath, key))
+			return err == nil
+		})
+	case '[':
+		n := 0
+		eachItem(data[i:end], func(s, e int) bool {
+			err = o.scanDuplicates(data, i+s, append(keyPath, strconv.Itoa(n)))
+			n++
+			return err == nil
+		})
+	}
+	return err
+}
+
+// find the value of key in object obj, the last one is used if the key is duplicate, same as Unmarshal
+func findKey(obj []byte, key string) (start, end int, found bool) {
+	eachMember(obj, func(quotedKey []byte, _, s, e int) bool {
+		if keyEquals(quotedKey, key) {
+			start, end, found = s, e, true
+		}
+		return true
+	})
+	return
+}
+
+// call fn with the quoted key, its offset and bounds of value of each member of object obj until it returns false
+func eachMember(obj []byte, fn func(quotedKey []byte, keyStart, start, end int) bool) {
+	i := skipSpace(obj, 1)
+	for i < len(obj) && obj[i] == '"' {
+		ks, ke := i, skipString(obj, i)
+		i = skipSpace(obj, skipSpace(obj, ke)+1) // skip ':'
+		end := skipValue(obj, i)
+		if !fn(obj[ks:ke], ks, i, end) {
+			return
+		}
+		i = skipSpace(obj, end)
+		if i < len(obj) && obj[i] == ',' {
+			i = skipSpace(obj, i+1)
+		}
+	}
+}
+
+func keyEquals(quoted []byte, key string) bool {
+	if !hasEscape(quoted) {
+		return string(quoted[1:len(quoted)-1]) == key
+	}
+	k, ok := decodeKey(quoted)
+	return ok && k == key
+}
+
+// decode quoted key with escapes
+func decodeKey(quoted []byte) (key string, ok bool) {
+	ok = json.Unmarshal(quoted, &key) == nil
+	return
+}
+
+// find the item at index idx of array arr, negative index counts from the end
+func findIndex(arr []byte, idx int) (start, end int, found bool) {
+	if idx < 0 {
+		n := 0
+		eachItem(arr, func(start, end int) bool {
+			n++
+			return true
+		})
+		if idx += n; idx < 0 {
+			return 0, 0, false
+		}
+	}
+	eachItem(arr, func(s, e int) bool {
+		if idx == 0 {
+			start, end, found = s, e, true
+			return false
+		}
+		idx--
+		return true
+	})
+	return
+}
+
+// call fn with bounds of each item of array arr until it returns false
+func eachItem(arr []byte, fn func(start, end int) bool) {
+	i := skipSpace(arr, 1)
+	for i < len(arr) && arr[i] != ']' {
+		end := skipValue(arr, i)
+		if !fn(i, end) {
+			return
+		}
+		i = skipSpace(arr, end)
+		if i < len(arr) && arr[i] == ',' {
+			i = skipSpace(arr, i+1)
+		}
+	}
+}
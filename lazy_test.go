@@ -0,0 +1,206 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestLazyJsonMap(t *testing.T) {
+	data := ` {"a":{"b":{"i":1234567890, "s":"str", "e":"x\"y\u00e9", "f":1.5}, "arr":[1, [2, 3], {"k":"v"}]},
+		"x\"y":true, "n":null, "empty":{}, "ea":[], "dup":1, "dup":2, "o":{"a":1, "a":2}, "long":7095620078347567873} `
+	for _, useNumber := range []bool{false, true} {
+		var opts []jsonmap.Option
+		if useNumber {
+			opts = append(opts, jsonmap.UseNumber())
+		}
+		lz, err := jsonmap.NewLazyJsonMap([]byte(data), opts...)
+		if err != nil {
+			t.Fatalf("NewLazyJsonMap failed: %v", err)
+		}
+		jm, _ := jsonmap.Unmarshal([]byte(data), useNumber)
+
+		// same results as JsonMap
+		keyPaths := [][]string{
+			{"a", "b", "i"}, {"a", "b", "s"}, {"a", "b", "e"}, {"a", "b", "f"}, {"a", "arr", "0"}, {"a", "arr", "1", "-1"},
+			{"a", "arr", "2", "k"}, {"a", "arr", "-4"}, {"a", "arr", "3"}, {"a", "arr", "x"}, {"a", "b", "s", "x"},
+			{`x"y`}, {"n"}, {"n", "x"}, {"empty", "x"}, {"ea", "0"}, {"none"}, {"a", "none", "x"}, {"long"}, {"a", "arr"},
+			{"dup"}, {"o", "a"},
+		}
+		for _, keyPath := range keyPaths {
+			v1, f1, e1 := jm.RGet(keyPath, "def")
+			v2, f2, e2 := lz.RGet(keyPath, "def")
+			if !reflect.DeepEqual(v1, v2) || f1 != f2 || fmt.Sprint(e1) != fmt.Sprint(e2) {
+				t.Fatalf("RGet %v failed: got (%v, %v, %v), expect (%v, %v, %v)", keyPath, v2, f2, e2, v1, f1, e1)
+			}
+			i1, f1, e1 := jm.RGetInt64(keyPath, -1)
+			i2, f2, e2 := lz.RGetInt64(keyPath, -1)
+			if i1 != i2 || f1 != f2 || fmt.Sprint(e1) != fmt.Sprint(e2) {
+				t.Fatalf("RGetInt64 %v failed: got (%v, %v, %v), expect (%v, %v, %v)", keyPath, i2, f2, e2, i1, f1, e1)
+			}
+		}
+		if s, f, e := lz.DGetString(`a.b.e`, ""); s != "x\"yé" || !f || e != nil {
+			t.Fatalf("DGetString failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := jsonmap.PGet(lz, "/a/arr/1/0", 0); s != 2 || !f || e != nil {
+			t.Fatalf("PGet failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := lz.RGetIntSlice([]string{"a", "arr", "1"}, nil); !reflect.DeepEqual(s, []int{2, 3}) || !f || e != nil {
+			t.Fatalf("RGetIntSlice failed: got (%v, %v, %v)", s, f, e)
+		}
+		m1, f, e := lz.GetSubMap("a", nil)
+		m2, _, _ := lz.GetSubMap("a", nil)
+		if !f || e != nil || !reflect.DeepEqual(map[string]interface{}(m1), jm["a"]) || reflect.ValueOf(m1).Pointer() != reflect.ValueOf(m2).Pointer() {
+			t.Fatalf("GetSubMap should be memoized: got (%v, %v, %v)", m1, f, e)
+		}
+		if _, _, e := lz.GetSubMap("dup", nil); !errors.Is(e, jsonmap.ErrNotMap) {
+			t.Fatalf("GetSubMap by number failed: got %v", e)
+		}
+		// the last duplicate key is used, whether it's scanned or decoded
+		if i, _, _ := lz.GetInt("dup", 0); i != 2 {
+			t.Fatalf("the last duplicate key should be used: got %v", i)
+		}
+		if o, _, _ := lz.GetSubMap("o", nil); o["a"] != jm["o"].(map[string]interface{})["a"] {
+			t.Fatalf("the last duplicate key should be used in decoded object: got %v", o)
+		}
+		if raw, f, e := lz.RawAt("a", "arr", "1"); string(raw) != "[2, 3]" || !f || e != nil {
+			t.Fatalf("RawAt failed: got (%s, %v, %v)", raw, f, e)
+		}
+		if m := lz.Map(); !reflect.DeepEqual(m, jm) {
+			t.Fatalf("Map failed: got %v, expect %v", m, jm)
+		}
+	}
+
+	lz, _ := jsonmap.NewLazyJsonMap([]byte(`{"f":"1.5"}`), jsonmap.WithConvPolicy(jsonmap.ConvPolicy{Coerce: true, Fraction: jsonmap.Round}))
+	if i, f, e := lz.GetInt("f", 0); i != 2 || !f || e != nil {
+		t.Fatalf("GetInt with policy failed: got (%v, %v, %v)", i, f, e)
+	}
+	for _, data := range []string{`[1]`, `{"a":}`, ``, `{"a":1} x`} {
+		if _, err := jsonmap.NewLazyJsonMap([]byte(data)); err == nil {
+			t.Fatalf("NewLazyJsonMap by %s should fail", data)
+		}
+	}
+	// errors of decoding a scalar are not lost
+	lz, _ = jsonmap.NewLazyJsonMap([]byte(`{"n":1e400}`))
+	if i, f, e := lz.GetInt("n", 7); i != 7 || !f || e == nil || errors.Is(e, jsonmap.ErrTypeMismatch) {
+		t.Fatalf("GetInt of 1e400 should fail by decoding: got (%v, %v, %v)", i, f, e)
+	}
+	if _, _, e := jsonmap.Get(lz, "n", 0); e == nil || errors.Is(e, jsonmap.ErrTypeMismatch) {
+		t.Fatalf("Get of 1e400 should fail by decoding: got %v", e)
+	}
+	var zero jsonmap.LazyJsonMap
+	if i, f, e := zero.GetInt("x", 1); i != 1 || f || e != nil {
+		t.Fatalf("zero LazyJsonMap failed: got (%v, %v, %v)", i, f, e)
+	}
+}
+
+func TestLazyJsonMapDuplicateKeys(t *testing.T) {
+	data := ` {"a":{"x":1, "y":[{"k":1, "k":2}]}, "a":{"x":2}}`
+	_, err := jsonmap.NewLazyJsonMap([]byte(data), jsonmap.DisallowDuplicateKeys())
+	var de *jsonmap.DuplicateKeyError
+	if !errors.As(err, &de) || !reflect.DeepEqual(de.Path, []string{"a", "y", "0", "k"}) || de.FirstOffset != 20 || de.Offset != 27 {
+		t.Fatalf("NewLazyJsonMap with DisallowDuplicateKeys should fail: got %v", err)
+	}
+	var report []jsonmap.DuplicateKey
+	lz, err := jsonmap.NewLazyJsonMap([]byte(data), jsonmap.ReportDuplicateKeys(&report))
+	// decoding doesn't report again
+	_, _, _ = lz.RGet([]string{"a", "y"}, nil)
+	var expected []jsonmap.DuplicateKey
+	_, _ = jsonmap.UnmarshalWithOptions([]byte(data), jsonmap.ReportDuplicateKeys(&expected))
+	if err != nil || len(report) != 2 || !reflect.DeepEqual(report, expected) {
+		t.Fatalf("NewLazyJsonMap with ReportDuplicateKeys failed: got (%v, %v), expect %v", report, err, expected)
+	}
+	if v, _, _ := lz.RGetInt([]string{"a", "x"}, 0); v != 2 {
+		t.Fatalf("the last duplicate key should be used: got %v", v)
+	}
+}
+
+func TestLazyJsonMapLimits(t *testing.T) {
+	data := ` {"a":{"b":{"c":1}, "arr":[1, 2, 3], "s":"0123456789", "k":{"x":1, "y":2, "z":3}}}`
+	cases := []struct {
+		keyPath []string
+		opt     jsonmap.Option
+	}{
+		{[]string{"a", "b"}, jsonmap.MaxDepth(2)},
+		{[]string{"a", "b", "c"}, jsonmap.MaxDepth(2)},
+		{[]string{"a"}, jsonmap.MaxDepth(2)},
+		{[]string{"a", "s"}, jsonmap.MaxStringLen(3)},
+		{[]string{"a", "arr", "2"}, jsonmap.MaxArrayLen(2)},
+		{[]string{"a", "arr", "0"}, jsonmap.MaxArrayLen(2)},
+		{[]string{"a", "arr"}, jsonmap.MaxArrayLen(2)},
+		{[]string{"a", "k", "x"}, jsonmap.MaxKeys(2)},
+		{[]string{"a", "k"}, jsonmap.MaxKeys(2)},
+		{[]string{"a", "arr", "0"}, jsonmap.MaxStringLen(2)},
+	}
+	for _, c := range cases {
+		lz, err := jsonmap.NewLazyJsonMap([]byte(data), c.opt)
+		if err != nil {
+			t.Fatalf("NewLazyJsonMap failed: %v", err)
+		}
+		// same error as decoding the whole data
+		_, expected := jsonmap.UnmarshalWithOptions([]byte(data), c.opt)
+		_, _, err = lz.RGet(c.keyPath, nil)
+		var le, ee *jsonmap.LimitError
+		if !errors.As(err, &le) || !errors.As(expected, &ee) || !reflect.DeepEqual(le, ee) {
+			t.Fatalf("RGet %v should fail: got %v, expect %v", c.keyPath, err, expected)
+		}
+	}
+	lz, _ := jsonmap.NewLazyJsonMap([]byte(data), jsonmap.MaxDepth(2))
+	if _, _, err := lz.RGetSubMap([]string{"a", "b"}, nil); err == nil || !strings.Contains(err.Error(), `key "/a/b": limit exceeded: MaxDepth 2`) {
+		t.Fatalf("RGetSubMap should fail by MaxDepth: got %v", err)
+	}
+	// values not read are not checked
+	if s, f, e := lz.RGetString([]string{"a", "s"}, ""); s != "0123456789" || !f || e != nil {
+		t.Fatalf("RGetString failed: got (%v, %v, %v)", s, f, e)
+	}
+}
+
+// a large document of which only a few fields are read
+var lazyBenchData = func() []byte {
+	m := map[string]interface{}{}
+	for i := 0; i < 300; i++ {
+		m[fmt.Sprintf("field%03d", i)] = map[string]interface{}{"name": strings.Repeat("x", 20), "values": []int{1, 2, 3, i}}
+	}
+	m["a"] = map[string]interface{}{"b": map[string]interface{}{"i": 1234567890}}
+	data, _ := json.Marshal(m)
+	return data
+}()
+
+func BenchmarkUnmarshalRGetInt(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm, _ := jsonmap.Unmarshal(lazyBenchData, false)
+		_, _, _ = jm.RGetInt([]string{"a", "b", "i"}, 0)
+		_, _, _ = jm.RGetString([]string{"field100", "name"}, "")
+		_, _, _ = jm.RGetInt([]string{"field299", "values", "3"}, 0)
+	}
+}
+
+func BenchmarkLazyRGetInt(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lz, _ := jsonmap.NewLazyJsonMap(lazyBenchData)
+		_, _, _ = lz.RGetInt([]string{"a", "b", "i"}, 0)
+		_, _, _ = lz.RGetString([]string{"field100", "name"}, "")
+		_, _, _ = lz.RGetInt([]string{"field299", "values", "3"}, 0)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// MarshalOption configures Marshal and MarshalIndent
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	escapeHTML bool
+}
+
+// whether to escape &, < and > in strings, it's true by default as encoding/json
+func EscapeHTML(on bool) MarshalOption {
+	return func(o *marshalOptions) { o.escapeHTML = on }
+}
+
+// encode to compact json. Keys are sorted so the output is deterministic,
+// json.Number is written as is so no precision is lost.
+func (d JsonMap) Marshal(opts ...MarshalOption) ([]byte, error) {
+	return marshal(map[string]interface{}(d), "", "", opts)
+}
+
+// like Marshal but each element begins on a new line starting with prefix followed by copies of indent
+func (d JsonMap) MarshalIndent(prefix, indent string, opts ...MarshalOption) ([]byte, error) {
+	return marshal(map[string]interface{}(d), prefix, indent, opts)
+}
+
+// encode the value to compact json, a missing value is encoded as null
+func (v JsonValue) Marshal(opts ...MarshalOption) ([]byte, error) {
+	return marshal(v.v, "", "", opts)
+}
+
+func (v JsonValue) MarshalIndent(prefix, indent string, opts ...MarshalOption) ([]byte, error) {
+	return marshal(v.v, prefix, indent, opts)
+}
+
+func marshal(v interface{}, prefix, indent string, opts []MarshalOption) ([]byte, error) {
+	o := marshalOptions{escapeHTML: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(o.escapeHTML)
+	enc.SetIndent(prefix, indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode appends a newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// truncate b to at most n bytes followed by "...", without breaking utf8 characters
+func truncate(b []byte, n int) string {
+	if n <= 0 || len(b) <= n {
+		return string(b)
+	}
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	return string(b[:n]) + "..."
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestMarshal(t *testing.T) {
+	data := `{"s":"<a&b>", "long":7095620078347567873, "f":1.50, "arr":[1, {"z":null, "y":true}], "b":"中文"}`
+	jm, _ := jsonmap.Unmarshal([]byte(data), true)
+	b, err := jm.Marshal()
+	expected := `{"arr":[1,{"y":true,"z":null}],"b":"中文","f":1.50,"long":7095620078347567873,"s":"\u003ca\u0026b\u003e"}`
+	if err != nil || string(b) != expected {
+		t.Fatalf("Marshal failed: got (%s, %v)", b, err)
+	}
+	b, err = jm.Marshal(jsonmap.EscapeHTML(false))
+	expected = `{"arr":[1,{"y":true,"z":null}],"b":"中文","f":1.50,"long":7095620078347567873,"s":"<a&b>"}`
+	if err != nil || string(b) != expected {
+		t.Fatalf("Marshal without EscapeHTML failed: got (%s, %v)", b, err)
+	}
+	if s := jm.String(); s != expected {
+		t.Fatalf("String failed: got %s", s)
+	}
+	if s := jm.StringN(12); s != `{"arr":[1,{"...` {
+		t.Fatalf("StringN failed: got %s", s)
+	}
+	// don't break utf8 characters
+	if s := jm.StringN(36); s != `{"arr":[1,{"y":true,"z":null}],"b":"...` {
+		t.Fatalf("StringN failed: got %s", s)
+	}
+	if s := jm.StringN(1000); s != expected {
+		t.Fatalf("StringN failed: got %s", s)
+	}
+
+	b, err = jm.MarshalIndent("", "  ")
+	expected = `{
+  "arr": [
+    1,
+    {
+      "y": true,
+      "z": null
+    }
+  ],
+  "b": "中文",
+  "f": 1.50,
+  "long": 7095620078347567873,
+  "s": "\u003ca\u0026b\u003e"
+}`
+	if err != nil || string(b) != expected {
+		t.Fatalf("MarshalIndent failed: got (%s, %v)", b, err)
+	}
+
+	// round trip
+	jm2, _ := jsonmap.Unmarshal([]byte(jm.String()), true)
+	if jm2.String() != jm.String() {
+		t.Fatalf("round trip failed: got %s", jm2)
+	}
+
+	v, _ := jsonmap.UnmarshalValue([]byte(`[1, "x"]`))
+	if b, err := v.Index(1).Marshal(); err != nil || string(b) != `"x"` {
+		t.Fatalf("JsonValue.Marshal failed: got (%s, %v)", b, err)
+	}
+	if b, err := v.Index(2).Marshal(); err != nil || string(b) != `null` {
+		t.Fatalf("JsonValue.Marshal by missing value failed: got (%s, %v)", b, err)
+	}
+
+	nan := jsonmap.JsonMap{"x": math.NaN()}
+	if _, err := nan.Marshal(); err == nil {
+		t.Fatal("Marshal NaN should fail")
+	}
+	if s := nan.String(); s != "JsonMap(map[x:NaN])" {
+		t.Fatalf("String with NaN failed: got %s", s)
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// OrderedJsonMap is a json object which keeps the order of keys, in source order if it's decoded,
+// and new keys are appended at the end. It has the same getters as JsonMap and marshals keys in order.
+//
+// Objects returned by getters like RGet and GetSlice are converted to map[string]interface{},
+// use GetSubMap to get an ordered sub object. The zero value is an empty map.
+type OrderedJsonMap struct {
+	getters
+}
+
+func NewOrderedJsonMap() OrderedJsonMap {
+	return OrderedJsonMap{getters{orderedSource{obj: newOrderedObject()}}}
+}
+
+// decode a json object keeping the order of keys, options are the same as UnmarshalWithOptions.
+// If an object has the same key twice, the key keeps its first position and the last value.
+func UnmarshalOrdered(data []byte, opts ...Option) (OrderedJsonMap, error) {
+	o := newDecodeOptions(opts)
+	if err := o.checkSize(data); err != nil {
+		return OrderedJsonMap{}, err
+	}
+	d := o.newTokenDecoder(data)
+	d.ordered = true
+	v, err := d.decode()
+	if err != nil {
+		return OrderedJsonMap{}, err
+	}
+	obj, ok := v.(*orderedObject)
+	if !ok {
+		return OrderedJsonMap{}, errNotObject
+	}
+	return OrderedJsonMap{getters{orderedSource{obj, o.policy}}}, nil
+}
+
+// the object, it's created on the first modification of a zero value
+func (d *OrderedJsonMap) object() *orderedObject {
+	s, ok := d.src.(orderedSource)
+	if !ok {
+		s = orderedSource{obj: newOrderedObject()}
+		d.src = s
+	}
+	return s.obj
+}
+
+func (d OrderedJsonMap) source() orderedSource {
+	s, _ := d.src.(orderedSource)
+	if s.obj == nil {
+		s.obj = newOrderedObject()
+	}
+	return s
+}
+
+// bind a conversion policy
+func (d OrderedJsonMap) WithPolicy(p ConvPolicy) OrderedJsonMap {
+	s := d.source()
+	s.policy = p
+	return OrderedJsonMap{getters{s}}
+}
+
+// keys in order
+func (d OrderedJsonMap) Keys() []string {
+	return append([]string(nil), d.source().obj.keys...)
+}
+
+func (d OrderedJsonMap) Len() int {
+	return d.source().obj.len()
+}
+
+// set value of key, a new key is appended at the end, an existing key keeps its position.
+// val is converted to decoded json shapes the same as JsonMap.RSet.
+func (d *OrderedJsonMap) Set(key string, val interface{}) error {
+	v, err := normalizeValue(val)
+	if err != nil {
+		return err
+	}
+	d.object().set(key, v)
+	return nil
+}
+
+// remove key, return false if the key doesn't exist
+func (d *OrderedJsonMap) Delete(key string) bool {
+	return d.object().delete(key)
+}
+
+// deep merge src into d like DeepMergeMap: objects are merged recursively, other values are overwritten,
+// new keys are appended at the end in the order of src
+func (d *OrderedJsonMap) Merge(src OrderedJsonMap) {
+	d.object().merge(src.source().obj)
+}
+
+// like Merge but src is unordered, new keys are appended in sorted order
+func (d *OrderedJsonMap) MergeMap(src JsonMap) {
+	d.object().merge(toOrdered(map[string]interface{}(src)).(*orderedObject))
+}
+
+func (d OrderedJsonMap) GetSubMap(key string, def OrderedJsonMap) (val OrderedJsonMap, found bool, err error) {
+	return d.RGetSubMap([]string{key}, def)
+}
+
+func (d OrderedJsonMap) RGetSubMap(keyPath []string, def OrderedJsonMap) (val OrderedJsonMap, found bool, err error) {
+	if len(keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	s := d.source()
+	v, found, err := s.find(keyPath, false)
+	if !found || err != nil {
+		return def, found, err
+	}
+	switch t := v.(type) {
+	case *orderedObject:
+		return OrderedJsonMap{getters{orderedSource{t, s.policy}}}, true, nil
+	case map[string]interface{}:
+		return OrderedJsonMap{getters{orderedSource{toOrdered(t).(*orderedObject), s.policy}}}, true, nil
+	}
+	return def, true, notMapError(keyPath, v)
+}
+
+// convert to an unordered JsonMap
+func (d OrderedJsonMap) Map() JsonMap {
+	return toPlain(d.source().obj).(map[string]interface{})
+}
+
+// compact json in order of keys, see JsonMap.Marshal
+func (d OrderedJsonMap) Marshal(opts ...MarshalOption) ([]byte, error) {
+	return marshal(d.source().obj, "", "", opts)
+}
+
+func (d OrderedJsonMap) MarshalIndent(prefix, indent string, opts ...MarshalOption) ([]byte, error) {
+	return marshal(d.source().obj, prefix, indent, opts)
+}
+
+func (d OrderedJsonMap) MarshalJSON() ([]byte, error) {
+	return d.source().obj.MarshalJSON()
+}
+
+// compact json without HTML escaping
+func (d OrderedJsonMap) String() string {
+	b, err := d.Marshal(EscapeHTML(false))
+	if err != nil {
+		return d.Map().String()
+	}
+	return string(b)
+}
+
+//// orderedSource is the Getter of OrderedJsonMap
+
+type orderedSource struct {
+	obj    *orderedObject
+	policy ConvPolicy
+}
+
+func (s orderedSource) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	val, found, err = s.find(keyPath, false)
+	return toPlain(val), found, err
+}
+
+func (s orderedSource) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	val, found, err = s.find(keyPath, true)
+	return toPlain(val), found, err
+}
+
+func (s orderedSource) lookupKey(key string) (val interface{}, found bool, err error) {
+	val, found = s.obj.values[key]
+	return toPlain(val), found, nil
+}
+
+func (s orderedSource) convPolicy() ConvPolicy {
+	return s.policy
+}
+
+// find the value at keyPath, objects are not converted, see parseIndex for strict
+func (s orderedSource) find(keyPath []string, strict bool) (val interface{}, found bool, err error) {
+	var v interface{} = s.obj
+	for idx, key := range keyPath {
+		switch t := v.(type) {
+		case *orderedObject:
+			v, found = t.values[key]
+		case map[string]interface{}:
+			v, found = t[key]
+		case []interface{}:
+			i, ok := parseIndex(key, strict)
+			if !ok {
+				return nil, false, lookupError(keyPath, idx, v)
+			}
+			if i, found = arrayIndex(i, len(t)); found {
+				v = t[i]
+			}
+		default:
+			return nil, false, lookupError(keyPath, idx, v)
+		}
+		if !found {
+			return nil, false, nil
+		}
+	}
+	return v, true, nil
+}
+
+//// orderedObject is a json object with ordered keys
+
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{values: make(map[string]interface{})}
+}
+
+func (o *orderedObject) len() int {
+	return len(o.keys)
+}
+
+func (o *orderedObject) set(key string, val interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = val
+}
+
+func (o *orderedObject) delete(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (o *orderedObject) merge(src *orderedObject) {
+	for _, key := range src.keys {
+		srcObj, ok := asOrdered(src.values[key])
+		if !ok {
+			o.set(key, src.values[key])
+			continue
+		}
+		dstObj, ok := asOrdered(o.values[key])
+		if !ok {
+			dstObj = newOrderedObject()
+		}
+		o.set(key, dstObj)
+		dstObj.merge(srcObj)
+	}
+}
+
+// objects in v as *orderedObject, a plain map is converted with sorted keys
+func asOrdered(v interface{}) (*orderedObject, bool) {
+	switch t := v.(type) {
+	case *orderedObject:
+		return t, true
+	case map[string]interface{}:
+		return toOrdered(t).(*orderedObject), true
+	}
+	return nil, false
+}
+
+// keys are written in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	// the caller's encoder escapes HTML if required
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // newline by Encode
+		buf.WriteByte(':')
+		if err := enc.Encode(o.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// convert ordered objects in v to map[string]interface{}
+func toPlain(v interface{}) interface{} {
+	switch t := v.(type) {
+	case *orderedObject:
+		m := make(map[string]interface{}, len(t.values))
+		for k, i := range t.values {
+			m[k] = toPlain(i)
+		}
+		return m
+	case []interface{}:
+		if !hasOrdered(t) {
+			return t
+		}
+		a := make([]interface{}, len(t))
+		for i, item := range t {
+			a[i] = toPlain(item)
+		}
+		return a
+	}
+	return v
+}
+
+// whether any ordered object is in a
+func hasOrdered(a []interface{}) bool {
+	for _, item := range a {
+		switch t := item.(type) {
+		case *orderedObject:
+			return true
+		case []interface{}:
+			if hasOrdered(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convert maps in v to ordered objects with sorted keys
+func toOrdered(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		o := newOrderedObject()
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.set(k, toOrdered(t[k]))
+		}
+		return o
+	case JsonMap:
+		return toOrdered(map[string]interface{}(t))
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, item := range t {
+			a[i] = toOrdered(item)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestUnmarshalOrdered(t *testing.T) {
+	data := `{"z":1, "a":{"y":"<b>", "x":[{"q":1, "p":2}]}, "m":1.50, "z":3}`
+	om, err := jsonmap.UnmarshalOrdered([]byte(data), jsonmap.UseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := om.Keys(); !reflect.DeepEqual(keys, []string{"z", "a", "m"}) || om.Len() != 3 {
+		t.Fatalf("Keys failed: got %v", keys)
+	}
+	if v, found, err := om.RGetInt([]string{"a", "x", "0", "p"}, 0); v != 2 || !found || err != nil {
+		t.Fatalf("RGetInt failed: got (%v, %v, %v)", v, found, err)
+	}
+	if v, found, err := om.GetInt("z", 0); v != 3 || !found || err != nil {
+		t.Fatalf("GetInt failed: got (%v, %v, %v)", v, found, err)
+	}
+	if v, found, err := om.RGet([]string{"a", "x", "-1"}, nil); !found || err != nil ||
+		!reflect.DeepEqual(v, map[string]interface{}{"q": json.Number("1"), "p": json.Number("2")}) {
+		t.Fatalf("RGet failed: got (%v, %v, %v)", v, found, err)
+	}
+	if _, found, err := om.RGet([]string{"z", "k"}, nil); found || !errors.Is(err, jsonmap.ErrNotMap) {
+		t.Fatalf("RGet through scalar should fail: got (%v, %v)", found, err)
+	}
+	b, err := om.Marshal()
+	expected := `{"z":3,"a":{"y":"\u003cb\u003e","x":[{"q":1,"p":2}]},"m":1.50}`
+	if err != nil || string(b) != expected {
+		t.Fatalf("Marshal failed: got (%s, %v)", b, err)
+	}
+	expected = `{"z":3,"a":{"y":"<b>","x":[{"q":1,"p":2}]},"m":1.50}`
+	if s := om.String(); s != expected {
+		t.Fatalf("String failed: got %s", s)
+	}
+	b, err = om.MarshalIndent("", " ")
+	expected = "{\n \"z\": 3,\n \"a\": {\n  \"y\": \"\\u003cb\\u003e\",\n  \"x\": [\n   {\n    \"q\": 1,\n    \"p\": 2\n   }\n  ]\n },\n \"m\": 1.50\n}"
+	if err != nil || string(b) != expected {
+		t.Fatalf("MarshalIndent failed: got (%s, %v)", b, err)
+	}
+
+	sub, found, err := om.GetSubMap("a", jsonmap.OrderedJsonMap{})
+	if !found || err != nil || !reflect.DeepEqual(sub.Keys(), []string{"y", "x"}) {
+		t.Fatalf("GetSubMap failed: got (%v, %v, %v)", sub, found, err)
+	}
+	if _, found, err := om.GetSubMap("m", jsonmap.OrderedJsonMap{}); !found || !errors.Is(err, jsonmap.ErrNotMap) {
+		t.Fatalf("GetSubMap on number should fail: got (%v, %v)", found, err)
+	}
+	if m := om.Map(); !reflect.DeepEqual(m["a"].(map[string]interface{})["y"], "<b>") {
+		t.Fatalf("Map failed: got %v", m)
+	}
+
+	if _, err := jsonmap.UnmarshalOrdered([]byte(`[1]`)); err == nil {
+		t.Fatal("array root should fail")
+	}
+	if _, err := jsonmap.UnmarshalOrdered([]byte(`{"a":1`)); err == nil {
+		t.Fatal("bad json should fail")
+	}
+}
+
+func TestOrderedJsonMapSetAndMerge(t *testing.T) {
+	var om jsonmap.OrderedJsonMap
+	if om.Len() != 0 || om.String() != "{}" {
+		t.Fatalf("zero value should be empty: got %s", om.String())
+	}
+	om.Set("b", 1)
+	om.Set("a", map[string]interface{}{"y": 1, "x": 2})
+	om.Set("b", 2)
+	if s := om.String(); s != `{"b":2,"a":{"x":2,"y":1}}` {
+		t.Fatalf("Set failed: got %s", s)
+	}
+	if err := om.Set("p", uint16(80)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, err := om.GetInt64("p", 0); v != 80 || err != nil {
+		t.Fatalf("GetInt64 of uint16 failed: got (%v, %v)", v, err)
+	}
+	if om.Set("c", func() {}) == nil || !om.Delete("p") {
+		t.Fatal("Set of func should fail")
+	}
+	if !om.Delete("b") || om.Delete("b") {
+		t.Fatal("Delete failed")
+	}
+
+	src, _ := jsonmap.UnmarshalOrdered([]byte(`{"c":true, "a":{"z":{"k":1}, "y":3}}`))
+	om.Merge(src)
+	if s := om.String(); s != `{"a":{"x":2,"y":3,"z":{"k":1}},"c":true}` {
+		t.Fatalf("Merge failed: got %s", s)
+	}
+	// merged objects are copied
+	om.Set("c", false)
+	if v, _, _ := src.GetBool("c", false); !v {
+		t.Fatal("Merge should not share src")
+	}
+
+	om.MergeMap(jsonmap.JsonMap{"e": 1.5, "d": nil, "a": map[string]interface{}{"w": "w"}})
+	if s := om.String(); s != `{"a":{"x":2,"y":3,"z":{"k":1},"w":"w"},"c":false,"d":null,"e":1.5}` {
+		t.Fatalf("MergeMap failed: got %s", s)
+	}
+	if v, found, err := om.WithPolicy(jsonmap.ConvPolicy{Coerce: true}).GetString("e", ""); v != "1.5" || !found || err != nil {
+		t.Fatalf("WithPolicy failed: got (%v, %v, %v)", v, found, err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+// decode only the values at keyPaths, other subtrees are skipped without being decoded.
+// Arrays on the way of a key path are decoded whole, e.g. for ["items", "0", "id"] the whole "items" array is decoded.
+// missing are the key paths not found. Options are the same as UnmarshalWithOptions, they apply to decoded values
+// and members of objects on the way of key paths, so depth counts from the top level object.
+// Skipped values are not checked except by MaxBytes.
+func UnmarshalPaths(data []byte, keyPaths [][]string, opts ...Option) (jm JsonMap, missing [][]string, err error) {
+	o := newDecodeOptions(opts)
+	start, end, err := o.scanObject(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := &pathNode{}
+	for _, keyPath := range keyPaths {
+		root.add(keyPath)
+	}
+	v, err := o.decodePaths(data, start, end, root, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	jm = v.(map[string]interface{})
+	for _, keyPath := range keyPaths {
+		if _, found, _ := jm.lookup(keyPath); !found {
+			missing = append(missing, keyPath)
+		}
+	}
+	return jm, missing, nil
+}
+
+// trie of key paths
+type pathNode struct {
+	children map[string]*pathNode
+	leaf     bool // the whole value is required
+}
+
+func (n *pathNode) add(keyPath []string) {
+	for _, key := range keyPath {
+		if n.leaf {
+			return
+		}
+		if n.children == nil {
+			n.children = make(map[string]*pathNode)
+		}
+		c, ok := n.children[key]
+		if !ok {
+			c = &pathNode{}
+			n.children[key] = c
+		}
+		n = c
+	}
+	n.leaf, n.children = true, nil
+}
+
+// decode the value data[start:end] at keyPath by node n, only objects are decoded partially
+func (o *decodeOptions) decodePaths(data []byte, start, end int, n *pathNode, keyPath []string) (interface{}, error) {
+	if n.leaf || data[start] != '{' {
+		return o.unmarshalAt(data, start, end, keyPath)
+	}
+	if o.maxDepth > 0 && len(keyPath) >= o.maxDepth {
+		return nil, &LimitError{Limit: "MaxDepth", Max: o.maxDepth, Path: copyKeyPath(keyPath), Offset: int64(start + 1)}
+	}
+	m := make(map[string]interface{})
+	// offsets of all keys, only used to check duplicates and count keys
+	var offsets map[string]int64
+	if o.checkDuplicates() || o.maxKeys > 0 {
+		offsets = make(map[string]int64)
+	}
+	var err error
+	prevEnd := start // end of the previous member, where the decoder stops before reading a key
+	eachMember(data[start:end], func(quotedKey []byte, ks, s, e int) bool {
+		offset := int64(start + ks)
+		if o.maxKeys > 0 && len(offsets) >= o.maxKeys {
+			err = &LimitError{Limit: "MaxKeys", Max: o.maxKeys, Path: copyKeyPath(keyPath), Offset: int64(prevEnd)}
+			return false
+		}
+		prevEnd = start + e
+		if o.maxStringLen > 0 && len(quotedKey)-2 > o.maxStringLen {
+			err = &LimitError{Limit: "MaxStringLen", Max: o.maxStringLen, Path: copyKeyPath(keyPath), Offset: offset}
+			return false
+		}
+		var key string
+		var c *pathNode
+		if hasEscape(quotedKey) {
+			key, _ = decodeKey(quotedKey)
+			c = n.children[key]
+		} else if c = n.children[string(quotedKey[1:len(quotedKey)-1])]; c != nil || offsets != nil {
+			key = string(quotedKey[1 : len(quotedKey)-1])
+		}
+		if offsets != nil {
+			if first, ok := offsets[key]; !ok {
+				offsets[key] = offset
+			} else if o.checkDuplicates() {
+				dup := DuplicateKey{Path: append(copyKeyPath(keyPath), key), FirstOffset: first, Offset: offset}
+				if err = o.duplicate(dup); err != nil {
+					return false
+				}
+			}
+		}
+		if c == nil {
+			return true
+		}
+		// the last one is used if the key is duplicate, same as Unmarshal
+		m[key], err = o.decodePaths(data, start+s, start+e, c, append(keyPath, key))
+		return err == nil
+	})
+	return m, err
+}
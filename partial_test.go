@@ -0,0 +1,110 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestUnmarshalPaths(t *testing.T) {
+	data := `{"a":{"b":{"i":1, "s":"str"}, "c":[1, {"d":2}], "skip":{"x":[1, 2]}}, "k\"q":true, "n":1.5,
+		"dup":{"x":1}, "dup":{"y":2}, "skip":"xxx"}`
+	keyPaths := [][]string{{"a", "b", "i"}, {"a", "c", "1", "d"}, {`k"q`}, {"n"}, {"dup", "y"}, {"dup", "x"},
+		{"a", "b", "none"}, {"a", "b", "i", "x"}, {"none"}}
+	jm, missing, err := jsonmap.UnmarshalPaths([]byte(data), keyPaths, jsonmap.UseNumber())
+	if err != nil {
+		t.Fatalf("UnmarshalPaths failed: %v", err)
+	}
+	expected := jsonmap.JsonMap{
+		"a":   map[string]interface{}{"b": map[string]interface{}{"i": json.Number("1")}, "c": []interface{}{json.Number("1"), map[string]interface{}{"d": json.Number("2")}}},
+		`k"q`: true,
+		"n":   json.Number("1.5"),
+		"dup": map[string]interface{}{"y": json.Number("2")},
+	}
+	if !reflect.DeepEqual(jm, expected) {
+		t.Fatalf("UnmarshalPaths failed: got %v, expect %v", jm, expected)
+	}
+	if !reflect.DeepEqual(missing, keyPaths[5:]) {
+		t.Fatalf("UnmarshalPaths missing failed: got %v", missing)
+	}
+	if d, f, e := jm.RGetInt([]string{"a", "c", "1", "d"}, 0); d != 2 || !f || e != nil {
+		t.Fatalf("RGetInt failed: got (%v, %v, %v)", d, f, e)
+	}
+
+	// a shorter key path covers the longer
+	jm, missing, err = jsonmap.UnmarshalPaths([]byte(data), [][]string{{"a", "b", "s"}, {"a", "b"}})
+	if err != nil || len(missing) != 0 || !reflect.DeepEqual(jm["a"], map[string]interface{}{"b": map[string]interface{}{"i": 1.0, "s": "str"}}) {
+		t.Fatalf("UnmarshalPaths failed: got (%v, %v, %v)", jm, missing, err)
+	}
+	jm, _, err = jsonmap.UnmarshalPaths([]byte(data), [][]string{{}})
+	if full, _ := jsonmap.Unmarshal([]byte(data), false); err != nil || !reflect.DeepEqual(jm, full) {
+		t.Fatalf("UnmarshalPaths by empty key path failed: got (%v, %v)", jm, err)
+	}
+
+	if _, _, err = jsonmap.UnmarshalPaths([]byte(`{"a":[[[1]]], "b":[[[1]]]}`), [][]string{{"a"}}, jsonmap.MaxDepth(2)); !errors.Is(err, jsonmap.ErrLimitExceeded) {
+		t.Fatalf("UnmarshalPaths with MaxDepth failed: got %v", err)
+	}
+	for _, data := range []string{`[1]`, `{"a":}`} {
+		if _, _, err = jsonmap.UnmarshalPaths([]byte(data), [][]string{{"a"}}); err == nil {
+			t.Fatalf("UnmarshalPaths by %s should fail", data)
+		}
+	}
+}
+
+func TestUnmarshalPathsOptions(t *testing.T) {
+	cases := []struct {
+		data     string
+		keyPaths [][]string
+		opt      jsonmap.Option
+	}{
+		{`{"a":{"x":1}, "a":{"x":2}}`, [][]string{{"a", "x"}}, jsonmap.DisallowDuplicateKeys()},
+		{`{"a":{"x":1, "y":[{"k":1, "k":2}]}}`, [][]string{{"a", "y"}}, jsonmap.DisallowDuplicateKeys()},
+		{`{"a":1, "b":2, "c":3}`, [][]string{{"a"}}, jsonmap.MaxKeys(2)},
+		{`{"a":{"b":{"c":{"d":1}}}}`, [][]string{{"a", "b", "c", "d"}}, jsonmap.MaxDepth(2)},
+		{`{"a":{"b":{"c":{"d":1}}}}`, [][]string{{"a"}}, jsonmap.MaxDepth(2)},
+		{`{"a":{"b":[[1, 2]]}}`, [][]string{{"a", "b"}}, jsonmap.MaxArrayLen(1)},
+		{`{"a":1, "long key":2}`, [][]string{{"a"}}, jsonmap.MaxStringLen(4)},
+	}
+	for _, c := range cases {
+		_, _, err := jsonmap.UnmarshalPaths([]byte(c.data), c.keyPaths, c.opt)
+		_, expected := jsonmap.UnmarshalWithOptions([]byte(c.data), c.opt)
+		if err == nil || expected == nil || err.Error() != expected.Error() {
+			t.Fatalf("UnmarshalPaths(%s, %v) should fail as UnmarshalWithOptions: got %v, expect %v", c.data, c.keyPaths, err, expected)
+		}
+	}
+
+	data := `{"a":{"x":1, "x":2, "y":{"k":1, "k":2}}, "a":{"x":3}, "skip":{"z":1, "z":2}}`
+	var report, expected []jsonmap.DuplicateKey
+	jm, _, err := jsonmap.UnmarshalPaths([]byte(data), [][]string{{"a"}}, jsonmap.ReportDuplicateKeys(&report))
+	_, _ = jsonmap.UnmarshalWithOptions([]byte(data), jsonmap.ReportDuplicateKeys(&expected))
+	// skipped values are not checked
+	if err != nil || jm.String() != `{"a":{"x":3}}` || !reflect.DeepEqual(report, expected[:3]) || len(expected) != 4 {
+		t.Fatalf("UnmarshalPaths with ReportDuplicateKeys failed: got (%s, %v, %v), expect %v", jm, report, err, expected)
+	}
+}
+
+func BenchmarkUnmarshalPaths(b *testing.B) {
+	keyPaths := [][]string{{"a", "b", "i"}, {"field100", "name"}, {"field299", "values"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm, _, _ := jsonmap.UnmarshalPaths(lazyBenchData, keyPaths)
+		_, _, _ = jm.RGetInt([]string{"a", "b", "i"}, 0)
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+//// JSON Patch (RFC 6902)
+
+// PatchOperation is an operation of JSON Patch, paths are JSON Pointers
+type PatchOperation struct {
+	Op    string      // add, remove, replace, move, copy or test
+	Path  string      // target location
+	From  string      // source location of move and copy
+	Value interface{} // value of add, replace and test, nil is null
+}
+
+// Patch is a JSON Patch document, operations are applied in order
+type Patch []PatchOperation
+
+// decode a JSON Patch document, values are decoded by opts the same as UnmarshalWithOptions.
+// Errors of operations are *PatchError.
+func DecodePatch(data []byte, opts ...Option) (Patch, error) {
+	o := newDecodeOptions(opts)
+	v, err := o.unmarshalValue(data)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("jsonmap: %w: got %s but expected array", ErrInvalidPatch, typeName(v))
+	}
+	patch := make(Patch, len(items))
+	for i, item := range items {
+		op, err := decodePatchOperation(item)
+		if err != nil {
+			return nil, &PatchError{Index: i, Op: op.Op, Err: err}
+		}
+		patch[i] = op
+	}
+	return patch, nil
+}
+
+func decodePatchOperation(item interface{}) (op PatchOperation, err error) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return op, fmt.Errorf("%w: got %s but expected object", ErrInvalidPatch, typeName(item))
+	}
+	member := func(name string) string {
+		s, ok := m[name].(string)
+		if !ok && err == nil {
+			err = fmt.Errorf("%w: member %q is missing or not string", ErrInvalidPatch, name)
+		}
+		return s
+	}
+	op.Op = member("op")
+	op.Path = member("path")
+	switch op.Op {
+	case "move", "copy":
+		op.From = member("from")
+	case "add", "replace", "test":
+		if op.Value, ok = m["value"]; !ok && err == nil {
+			err = fmt.Errorf("%w: member \"value\" is missing", ErrInvalidPatch)
+		}
+	case "remove":
+	default:
+		if err == nil {
+			err = fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+		}
+	}
+	return op, err
+}
+
+// apply the patch to a copy of jm and return it, jm is not changed even if an operation fails.
+// The returned error is a *PatchError naming the failing operation, use errors.Is to check the sentinel
+// like ErrNotFound or ErrTestFailed.
+func (p Patch) Apply(jm JsonMap) (JsonMap, error) {
+	var doc interface{} = copyValue(map[string]interface{}(jm))
+	for i, op := range p {
+		var err error
+		if doc, err = op.apply(doc); err == nil {
+			if _, ok := doc.(map[string]interface{}); !ok {
+				err = &PathError{Path: []string{}, Index: -1, Err: ErrNotMap, Expected: "map", Actual: typeName(doc)}
+			}
+		}
+		if err != nil {
+			return nil, &PatchError{Index: i, Op: op.Op, Err: err}
+		}
+	}
+	return JsonMap(doc.(map[string]interface{})), nil
+}
+
+// decode patch by opts and apply it to a copy of jm, see DecodePatch and Patch.Apply
+func ApplyPatch(jm JsonMap, patch []byte, opts ...Option) (JsonMap, error) {
+	p, err := DecodePatch(patch, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p.Apply(jm)
+}
+
+func (op PatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return doc, err
+	}
+	switch op.Op {
+	case "add":
+		return patchAdd(doc, path, copyValue(op.Value))
+	case "remove":
+		return patchRemove(doc, path)
+	case "replace":
+		return patchReplace(doc, path, copyValue(op.Value))
+	case "test":
+		v, err := patchGet(doc, path)
+		if err != nil {
+			return doc, err
+		}
+		if !jsonEqual(v, op.Value) {
+			return doc, &PathError{Path: path, Index: len(path) - 1, Err: ErrTestFailed}
+		}
+		return doc, nil
+	case "move", "copy":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return doc, err
+		}
+		v, err := patchGet(doc, from)
+		if err != nil {
+			return doc, err
+		}
+		if op.Op == "copy" {
+			return patchAdd(doc, path, copyValue(v))
+		}
+		if isPathPrefix(from, path) {
+			if len(from) == len(path) {
+				return doc, nil
+			}
+			return doc, fmt.Errorf("jsonmap: %w: can't move %q into itself", ErrInvalidPatch, op.From)
+		}
+		if doc, err = patchRemove(doc, from); err != nil {
+			return doc, err
+		}
+		return patchAdd(doc, path, v)
+	}
+	return doc, fmt.Errorf("jsonmap: %w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// value at keyPath, it must exist
+func patchGet(doc interface{}, keyPath []string) (v interface{}, err error) {
+	v = doc
+	for idx := range keyPath {
+		if v, err = patchChild(v, keyPath, idx); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// add to an object or insert into an array, the parent must exist
+func patchAdd(doc interface{}, keyPath []string, val interface{}) (interface{}, error) {
+	if len(keyPath) == 0 {
+		return val, nil
+	}
+	return updateParent(doc, keyPath, 0, func(parent interface{}, idx int) (interface{}, error) {
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			t[keyPath[idx]] = val
+			return t, nil
+		case []interface{}:
+			i, err := patchIndex(keyPath, idx, len(t), true)
+			if err != nil {
+				return parent, err
+			}
+			t = append(t, nil)
+			copy(t[i+1:], t[i:])
+			t[i] = val
+			return t, nil
+		}
+		return parent, lookupError(keyPath, idx, parent)
+	})
+}
+
+func patchRemove(doc interface{}, keyPath []string) (interface{}, error) {
+	if len(keyPath) == 0 {
+		return doc, fmt.Errorf("jsonmap: %w: can't remove the whole document", ErrInvalidPatch)
+	}
+	return updateParent(doc, keyPath, 0, func(parent interface{}, idx int) (interface{}, error) {
+		if _, err := patchChild(parent, keyPath, idx); err != nil {
+			return parent, err
+		}
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			delete(t, keyPath[idx])
+			return t, nil
+		case []interface{}:
+			i, _ := patchIndex(keyPath, idx, len(t), false)
+			return append(t[:i:i], t[i+1:]...), nil
+		}
+		return parent, nil
+	})
+}
+
+func patchReplace(doc interface{}, keyPath []string, val interface{}) (interface{}, error) {
+	if len(keyPath) == 0 {
+		return val, nil
+	}
+	return updateParent(doc, keyPath, 0, func(parent interface{}, idx int) (interface{}, error) {
+		if _, err := patchChild(parent, keyPath, idx); err != nil {
+			return parent, err
+		}
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			t[keyPath[idx]] = val
+		case []interface{}:
+			i, _ := patchIndex(keyPath, idx, len(t), false)
+			t[i] = val
+		}
+		return parent, nil
+	})
+}
+
+// call fn with the parent of keyPath, which is the value at keyPath[0:len-1], and store the parent fn returns
+// back into v, which is the value at keyPath[0:idx]. Return the new v.
+func updateParent(v interface{}, keyPath []string, idx int,
+	fn func(parent interface{}, idx int) (interface{}, error)) (interface{}, error) {
+	if idx == len(keyPath)-1 {
+		return fn(v, idx)
+	}
+	child, err := patchChild(v, keyPath, idx)
+	if err != nil {
+		return v, err
+	}
+	nc, err := updateParent(child, keyPath, idx+1, fn)
+	if err != nil {
+		return v, err
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		t[keyPath[idx]] = nc
+	case []interface{}:
+		i, _ := patchIndex(keyPath, idx, len(t), false)
+		t[i] = nc
+	}
+	return v, nil
+}
+
+// child of v, which is the value at keyPath[0:idx], by keyPath[idx]. It must exist.
+func patchChild(v interface{}, keyPath []string, idx int) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if c, ok := t[keyPath[idx]]; ok {
+			return c, nil
+		}
+		return nil, &PathError{Path: copyKeyPath(keyPath), Index: idx, Err: ErrNotFound}
+	case []interface{}:
+		i, err := patchIndex(keyPath, idx, len(t), false)
+		if err != nil {
+			return nil, err
+		}
+		return t[i], nil
+	}
+	return nil, lookupError(keyPath, idx, v)
+}
+
+// array index by keyPath[idx] as JSON Pointer requires: no sign or leading zero, and no negative index.
+// The end of array, n or "-", is accepted only if end is true.
+func patchIndex(keyPath []string, idx, n int, end bool) (int, error) {
+	key := keyPath[idx]
+	i := n
+	if key != "-" {
+		var err error
+		if i, err = strconv.Atoi(key); err != nil || !isPointerIndex(key) {
+			return 0, lookupError(keyPath, idx, []interface{}(nil))
+		}
+	}
+	if i > n || (i == n && !end) {
+		return 0, &PathError{Path: copyKeyPath(keyPath), Index: idx, Err: ErrIndexOutOfRange}
+	}
+	return i, nil
+}
+
+// whether keyPath starts with prefix
+func isPathPrefix(prefix, keyPath []string) bool {
+	if len(prefix) > len(keyPath) {
+		return false
+	}
+	for i, key := range prefix {
+		if keyPath[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+// deep copy of maps and arrays
+func copyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, i := range t {
+			m[k] = copyValue(i)
+		}
+		return m
+	case JsonMap:
+		return copyValue(map[string]interface{}(t))
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, item := range t {
+			a[i] = copyValue(item)
+		}
+		return a
+	}
+	return v
+}
+
+// equality of json values, numbers are equal if they are numerically equal, whichever type they are decoded as,
+// or any Go numeric kind if the patch is built in code
+func jsonEqual(a, b interface{}) bool {
+	if m, ok := b.(JsonMap); ok {
+		b = map[string]interface{}(m)
+	}
+	if _, ok := queryNumber(a); ok {
+		return numberEqual(a, b)
+	}
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			if yv, ok := y[k]; !ok || !jsonEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	case JsonMap:
+		return jsonEqual(map[string]interface{}(x), b)
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// exact numbers are compared exactly, others are compared as float64
+func numberEqual(a, b interface{}) bool {
+	if x, ok := exactNumber(a); ok {
+		if y, ok := exactNumber(b); ok {
+			return x.Cmp(y) == 0
+		}
+	}
+	x, ok1 := queryNumber(a)
+	y, ok2 := queryNumber(b)
+	return ok1 && ok2 && x == y
+}
+
+// json.Number or integer of any Go kind
+func exactNumber(v interface{}) (*big.Rat, bool) {
+	if n, ok := v.(json.Number); ok {
+		r, err := defaultConvPolicy.toRat(n, nil)
+		return r, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetUint64(rv.Uint()), true
+	}
+	return nil, false
+}
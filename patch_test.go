@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestApplyPatch(t *testing.T) {
+	cases := []struct {
+		doc, patch, expected string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add", "path":"/baz", "value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add", "path":"/foo/1", "value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add", "path":"/foo/-", "value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add", "path":"/foo/1", "value":null}]`, `{"foo":["bar",null]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove", "path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove", "path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace", "path":"/baz", "value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move", "from":"/foo/waldo", "path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move", "from":"/foo/1", "path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"move", "from":"/a", "path":"/a"}]`, `{"a":{"b":1}}`},
+		{`{"a":{"b":[1]}}`, `[{"op":"copy", "from":"/a/b", "path":"/c"}, {"op":"add", "path":"/c/0", "value":0}]`, `{"a":{"b":[1]},"c":[0,1]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test", "path":"/baz", "value":"qux"}, {"op":"test", "path":"/foo/1", "value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"a/b":{"m~n":1}}`, `[{"op":"test", "path":"/a~1b", "value":{"m~n":1.0}}, {"op":"remove", "path":"/a~1b/m~0n"}]`, `{"a/b":{}}`},
+		{`{"a":1}`, `[{"op":"replace", "path":"", "value":{"b":2}}]`, `{"b":2}`},
+	}
+	for _, c := range cases {
+		jm, _ := jsonmap.Unmarshal([]byte(c.doc), false)
+		res, err := jsonmap.ApplyPatch(jm, []byte(c.patch))
+		if err != nil || res.String() != c.expected {
+			t.Fatalf("ApplyPatch(%s, %s) failed: got (%s, %v)", c.doc, c.patch, res, err)
+		}
+		if origin, _ := jsonmap.Unmarshal([]byte(c.doc), false); jm.String() != origin.String() {
+			t.Fatalf("ApplyPatch(%s, %s) changed the original: got %s", c.doc, c.patch, jm)
+		}
+	}
+}
+
+func TestApplyPatchNumbers(t *testing.T) {
+	jm, _ := jsonmap.UnmarshalWithOptions([]byte(`{"n":12345678901234567890, "f":1.5, "i":1}`), jsonmap.SmartNumbers())
+	patch := `[{"op":"test", "path":"/n", "value":12345678901234567890}, {"op":"test", "path":"/f", "value":1.50},
+		{"op":"test", "path":"/i", "value":1.0}, {"op":"add", "path":"/m", "value":12345678901234567891}]`
+	res, err := jsonmap.ApplyPatch(jm.Map(), []byte(patch), jsonmap.UseNumber())
+	if err != nil || res.String() != `{"f":1.5,"i":1,"m":12345678901234567891,"n":12345678901234567890}` {
+		t.Fatalf("ApplyPatch failed: got (%s, %v)", res, err)
+	}
+	_, err = jsonmap.ApplyPatch(jm.Map(), []byte(`[{"op":"test", "path":"/n", "value":12345678901234567891}]`), jsonmap.UseNumber())
+	if !errors.Is(err, jsonmap.ErrTestFailed) {
+		t.Fatalf("test of different big numbers should fail: got %v", err)
+	}
+}
+
+func TestApplyPatchError(t *testing.T) {
+	doc := `{"a":{"b":[1, 2]}, "s":"s"}`
+	cases := []struct {
+		patch    string
+		sentinel error
+		msg      string
+	}{
+		{`[{"op":"add", "path":"/x", "value":1}, {"op":"remove", "path":"/a/c"}]`, jsonmap.ErrNotFound,
+			`jsonmap: patch operation 1 (remove): key "/a/c": not found`},
+		{`[{"op":"replace", "path":"/a/b/2", "value":1}]`, jsonmap.ErrIndexOutOfRange,
+			`jsonmap: patch operation 0 (replace): key "/a/b/2": index out of range`},
+		{`[{"op":"add", "path":"/a/b/01", "value":1}]`, jsonmap.ErrInvalidIndex,
+			`jsonmap: patch operation 0 (add): key "/a/b/01": invalid index at "/a/b"`},
+		{`[{"op":"add", "path":"/a/b/-1", "value":1}]`, jsonmap.ErrInvalidIndex, ""},
+		{`[{"op":"remove", "path":"/a/b/-"}]`, jsonmap.ErrIndexOutOfRange, ""},
+		{`[{"op":"add", "path":"/x/y", "value":1}]`, jsonmap.ErrNotFound, ""},
+		{`[{"op":"add", "path":"/s/y", "value":1}]`, jsonmap.ErrNotMap,
+			`jsonmap: patch operation 0 (add): key "/s/y": not map at "/s": got string but expected map or array`},
+		{`[{"op":"test", "path":"/a/b", "value":[2, 1]}]`, jsonmap.ErrTestFailed,
+			`jsonmap: patch operation 0 (test): key "/a/b": test failed`},
+		{`[{"op":"move", "from":"/a", "path":"/a/c"}]`, jsonmap.ErrInvalidPatch, ""},
+		{`[{"op":"remove", "path":""}]`, jsonmap.ErrInvalidPatch, ""},
+		{`[{"op":"replace", "path":"", "value":[]}]`, jsonmap.ErrNotMap, ""},
+		{`[{"op":"copy", "path":"/x"}]`, jsonmap.ErrInvalidPatch,
+			`jsonmap: patch operation 0 (copy): invalid patch: member "from" is missing or not string`},
+		{`[{"op":"add", "path":"/x"}]`, jsonmap.ErrInvalidPatch, ""},
+		{`[{"op":"inc", "path":"/x"}]`, jsonmap.ErrInvalidPatch, `jsonmap: patch operation 0 (inc): invalid patch: unknown op "inc"`},
+		{`[1]`, jsonmap.ErrInvalidPatch, ""},
+		{`{}`, jsonmap.ErrInvalidPatch, ""},
+	}
+	for _, c := range cases {
+		jm, _ := jsonmap.Unmarshal([]byte(doc), false)
+		res, err := jsonmap.ApplyPatch(jm, []byte(c.patch))
+		if res != nil || !errors.Is(err, c.sentinel) || (c.msg != "" && err.Error() != c.msg) {
+			t.Fatalf("ApplyPatch(%s) should fail with %v: got (%s, %v)", c.patch, c.sentinel, res, err)
+		}
+		if jm.String() != `{"a":{"b":[1,2]},"s":"s"}` {
+			t.Fatalf("ApplyPatch(%s) changed the original: got %s", c.patch, jm)
+		}
+	}
+	var pe *jsonmap.PatchError
+	_, err := jsonmap.ApplyPatch(jsonmap.JsonMap{}, []byte(`[{"op":"add", "path":"/a", "value":1}, {"op":"test", "path":"/a", "value":2}]`))
+	if !errors.As(err, &pe) || pe.Index != 1 || pe.Op != "test" {
+		t.Fatalf("PatchError failed: got %v", err)
+	}
+
+	// patch built in code
+	p := jsonmap.Patch{{Op: "add", Path: "/a", Value: jsonmap.JsonMap{"b": 1}}, {Op: "test", Path: "/a/b", Value: 1}}
+	if res, err := p.Apply(jsonmap.JsonMap{}); err != nil || res.String() != `{"a":{"b":1}}` {
+		t.Fatalf("Patch.Apply failed: got (%s, %v)", res, err)
+	}
+}
+
+func TestPatchTestBuiltInCode(t *testing.T) {
+	data := `{"n":1, "f":1.5, "arr":[1, 2], "o":{"k":[true, null, "s"]}, "big":12345678901234567891}`
+	p := jsonmap.Patch{
+		{Op: "test", Path: "/n", Value: 1},
+		{Op: "test", Path: "/n", Value: uint8(1)},
+		{Op: "test", Path: "/f", Value: float32(1.5)},
+		{Op: "test", Path: "/arr", Value: []interface{}{1, int64(2)}},
+		{Op: "test", Path: "/o", Value: jsonmap.JsonMap{"k": []interface{}{true, nil, "s"}}},
+	}
+	for _, opt := range []jsonmap.Option{jsonmap.UseNumber(), jsonmap.SmartNumbers(), jsonmap.WithConvPolicy(jsonmap.ConvPolicy{})} {
+		v, _ := jsonmap.UnmarshalWithOptions([]byte(data), opt)
+		if _, err := p.Apply(v.Map()); err != nil {
+			t.Fatalf("Patch.Apply failed: %v", err)
+		}
+		for _, op := range []jsonmap.PatchOperation{
+			{Op: "test", Path: "/n", Value: 2},
+			{Op: "test", Path: "/n", Value: "1"},
+			{Op: "test", Path: "/arr", Value: []interface{}{2, 1}},
+			{Op: "test", Path: "/o/k", Value: []interface{}{true, nil}},
+		} {
+			if _, err := (jsonmap.Patch{op}).Apply(v.Map()); !errors.Is(err, jsonmap.ErrTestFailed) {
+				t.Fatalf("test %v should fail: got %v", op.Value, err)
+			}
+		}
+	}
+	// exact numbers are compared exactly
+	v, _ := jsonmap.UnmarshalWithOptions([]byte(data), jsonmap.UseNumber())
+	if _, err := (jsonmap.Patch{{Op: "test", Path: "/big", Value: uint64(12345678901234567891)}}).Apply(v.Map()); err != nil {
+		t.Fatalf("test of big number failed: %v", err)
+	}
+	if _, err := (jsonmap.Patch{{Op: "test", Path: "/big", Value: uint64(12345678901234567890)}}).Apply(v.Map()); !errors.Is(err, jsonmap.ErrTestFailed) {
+		t.Fatalf("test of different big number should fail: got %v", err)
+	}
+}
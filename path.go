@@ -0,0 +1,216 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+// Path is a compiled key path for repeated lookups, it's immutable and safe for concurrent use
+type Path struct {
+	keyPath []string
+	pointer bool // array indexes are strict as JSON Pointer
+}
+
+// compile a key path, it's validated only once here
+func CompilePath(keyPath ...string) (Path, error) {
+	if len(keyPath) == 0 {
+		return Path{}, emptyPathError()
+	}
+	return Path{keyPath: append([]string(nil), keyPath...)}, nil
+}
+
+// like CompilePath but panics if failed, it's used to initialize global variables
+func MustCompilePath(keyPath ...string) Path {
+	p, err := CompilePath(keyPath...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// compile a dotted path like `a.b[2].c`, see ParsePath
+func CompileDottedPath(path string) (Path, error) {
+	keyPath, err := ParsePath(path)
+	if err != nil {
+		return Path{}, err
+	}
+	return Path{keyPath: keyPath}, nil
+}
+
+// compile a JSON Pointer like "/a/b/2/c", see ParsePointer
+func CompilePointer(pointer string) (Path, error) {
+	keyPath, err := ParsePointer(pointer)
+	if err != nil {
+		return Path{}, err
+	}
+	p, err := CompilePath(keyPath...)
+	if err != nil {
+		return Path{}, err
+	}
+	p.pointer = true
+	return p, nil
+}
+
+// returns a copy of the key path
+func (p Path) KeyPath() []string {
+	return append([]string(nil), p.keyPath...)
+}
+
+func (p Path) get(d Getter, def interface{}) (val interface{}, found bool, err error) {
+	if len(p.keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	if p.pointer {
+		val, found, err = d.pointerLookup(p.keyPath)
+	} else {
+		val, found, err = d.lookup(p.keyPath)
+	}
+	if !found || err != nil {
+		return def, found, err
+	}
+	return val, true, nil
+}
+
+//// get by compiled path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+// fetch origin value, no type assurance
+func (p Path) Get(d Getter, def interface{}) (val interface{}, found bool, err error) {
+	return p.get(d, def)
+}
+
+// this method ensures val’s type is same as def
+func (p Path) Any(d Getter, def interface{}) (val interface{}, found bool, err error) {
+	val, found, err = p.get(d, def)
+	if !found || err != nil {
+		return
+	}
+	val, err = d.convPolicy().toAny(val, def)
+	err = withPath(err, p.keyPath)
+	return
+}
+
+func (p Path) SubMap(d Getter, def JsonMap) (val JsonMap, found bool, err error) {
+	raw, found, err := p.get(d, def)
+	if !found || err != nil {
+		return def, found, err
+	}
+	v, ok := raw.(map[string]interface{})
+	if !ok {
+		return def, true, notMapError(p.keyPath, raw)
+	}
+	return JsonMap(v), true, nil
+}
+
+func (p Path) Str(d Getter, def string) (val string, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Bool(d Getter, def bool) (val bool, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Float64(d Getter, def float64) (val float64, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Float32(d Getter, def float32) (val float32, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Int64(d Getter, def int64) (val int64, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Uint64(d Getter, def uint64) (val uint64, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Int32(d Getter, def int32) (val int32, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Uint32(d Getter, def uint32) (val uint32, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Int(d Getter, def int) (val int, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+func (p Path) Uint(d Getter, def uint) (val uint, found bool, err error) {
+	return PathGet(p, d, def)
+}
+
+//// get slice by compiled path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (p Path) StringSlice(d Getter, def []string) (val []string, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) BoolSlice(d Getter, def []bool) (val []bool, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Float64Slice(d Getter, def []float64) (val []float64, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Float32Slice(d Getter, def []float32) (val []float32, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Int64Slice(d Getter, def []int64) (val []int64, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Uint64Slice(d Getter, def []uint64) (val []uint64, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Int32Slice(d Getter, def []int32) (val []int32, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) Uint32Slice(d Getter, def []uint32) (val []uint32, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) IntSlice(d Getter, def []int) (val []int, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+func (p Path) UintSlice(d Getter, def []uint) (val []uint, found bool, err error) {
+	return PathGetSlice(p, d, def)
+}
+
+// get value with type T by compiled path
+func PathGet[T Scalar](p Path, d Getter, def T) (val T, found bool, err error) {
+	raw, found, err := p.get(d, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = convert(d.convPolicy(), raw, def)
+	return val, true, withPath(err, p.keyPath)
+}
+
+// get slice with item type T by compiled path
+func PathGetSlice[T Scalar](p Path, d Getter, def []T) (val []T, found bool, err error) {
+	raw, found, err := p.get(d, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, idx, err := convertSlice[T](d.convPolicy(), raw)
+	if err != nil {
+		return def, true, withSliceIndex(err, p.keyPath, idx)
+	}
+	return val, true, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+var (
+	pathABI = jsonmap.MustCompilePath("a", "b", "i")
+	pathABS = jsonmap.MustCompilePath("a", "b", "s")
+)
+
+func TestPath(t *testing.T) {
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(jsonStrForPerfTest), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v, string = %v", err, useNumber, jsonStrForPerfTest)
+		}
+		if v, f, e := pathABI.Int(jm, 0); v != 1234567890 || !f || e != nil {
+			t.Fatalf("Path.Int failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := pathABS.Str(jm, ""); v != "str" || !f || e != nil {
+			t.Fatalf("Path.Str failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := pathABS.Int(jm, -1); v != -1 || !f || e == nil {
+			t.Fatalf("Path.Int failed: got (%v, %v, %v)", v, f, e)
+		}
+		p, _ := jsonmap.CompileDottedPath("a.b.b")
+		if v, f, e := p.Bool(jm, false); !v || !f || e != nil {
+			t.Fatalf("Path.Bool failed: got (%v, %v, %v)", v, f, e)
+		}
+		p, _ = jsonmap.CompilePointer("/a/none")
+		if v, f, e := p.Uint(jm, 1); v != 1 || f || e != nil {
+			t.Fatalf("Path.Uint failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := (jsonmap.Path{}).Int(jm, 1); v != 1 || f || e == nil {
+			t.Fatalf("Path.Int failed: got (%v, %v, %v)", v, f, e)
+		}
+	}
+	if _, err := jsonmap.CompilePath(); err == nil {
+		t.Fatal("CompilePath with empty keyPath should fail")
+	}
+	if _, err := jsonmap.CompilePointer(""); err == nil {
+		t.Fatal("CompilePointer with empty pointer should fail")
+	}
+	keyPath := []string{"a", "b"}
+	p := jsonmap.MustCompilePath(keyPath...)
+	keyPath[0] = "x"
+	if p.KeyPath()[0] != "a" {
+		t.Fatal("Path should be immutable")
+	}
+}
+
+func BenchmarkRGetInt(b *testing.B) {
+	jm, _ := jsonmap.Unmarshal([]byte(jsonStrForPerfTest), false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = jm.RGetInt([]string{"a", "b", "i"}, 0)
+	}
+}
+
+func BenchmarkPathInt(b *testing.B) {
+	jm, _ := jsonmap.Unmarshal([]byte(jsonStrForPerfTest), false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = pathABI.Int(jm, 0)
+	}
+}
+
+func BenchmarkRGetString(b *testing.B) {
+	jm, _ := jsonmap.Unmarshal([]byte(jsonStrForPerfTest), false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = jm.RGetString([]string{"a", "b", "s"}, "")
+	}
+}
+
+func BenchmarkPathString(b *testing.B) {
+	jm, _ := jsonmap.Unmarshal([]byte(jsonStrForPerfTest), false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = pathABS.Str(jm, "")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+//// JSON Pointer (RFC 6901)
+
+// parse a JSON Pointer like "/spec/containers/0/image" to a key path which can be used by RGet*,
+// "~1" is unescaped to "/" and "~0" to "~", the empty pointer "" refers to the whole document
+func ParsePointer(pointer string) (keyPath []string, err error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("pointer %q does not start with '/'", pointer)
+	}
+	keyPath = strings.Split(pointer[1:], "/")
+	for i, token := range keyPath {
+		if !strings.Contains(token, "~") {
+			continue
+		}
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("pointer %q has invalid escape in token %q", pointer, token)
+			}
+		}
+		keyPath[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return keyPath, nil
+}
+
+// format a key path to JSON Pointer, it's the reverse of ParsePointer
+func FormatPointer(keyPath []string) string {
+	var b strings.Builder
+	for _, k := range keyPath {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func pGet(d Getter, pointer string, def interface{}) (val interface{}, found bool, err error) {
+	keyPath, err := ParsePointer(pointer)
+	if err != nil {
+		return def, false, err
+	}
+	val, found, err = d.pointerLookup(keyPath)
+	if !found || err != nil {
+		return def, found, err
+	}
+	return val, true, nil
+}
+
+// decimal digits without leading zero
+func isPointerIndex(key string) bool {
+	if key == "" || (key[0] == '0' && len(key) > 1) {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// this method ensures val’s type is same as def
+func pGetAny(d Getter, pointer string, def interface{}) (val interface{}, found bool, err error) {
+	val, found, err = pGet(d, pointer, def)
+	if !found || err != nil {
+		return
+	}
+	val, err = d.convPolicy().toAny(val, def)
+	if err != nil {
+		err = withPointer(err, pointer)
+	}
+	return
+}
+
+// fetch origin value by JSON Pointer, no type assurance, errors are *PathError except syntax errors.
+// array index must be decimal digits without sign or leading zero, "-" is an error since it refers to a nonexistent element,
+// and an index too large for int is not found.
+func (d JsonMap) PGet(pointer string, def interface{}) (val interface{}, found bool, err error) {
+	return pGet(d, pointer, def)
+}
+
+// this method ensures val’s type is same as def
+func (d JsonMap) PGetAny(pointer string, def interface{}) (val interface{}, found bool, err error) {
+	return pGetAny(d, pointer, def)
+}
+
+// fill key path of the error returned by type conversion
+func withPointer(err error, pointer string) error {
+	keyPath, _ := ParsePointer(pointer)
+	return withPath(err, keyPath)
+}
+
+func (d JsonMap) PGetSubMap(pointer string, def JsonMap) (val JsonMap, found bool, err error) {
+	raw, found, err := pGet(d, pointer, def)
+	if !found || err != nil {
+		return def, found, err
+	}
+	v, ok := raw.(map[string]interface{})
+	if !ok {
+		keyPath, _ := ParsePointer(pointer)
+		return def, true, notMapError(keyPath, raw)
+	}
+	return JsonMap(v), true, nil
+}
+
+// get value with type T by JSON Pointer
+func PGet[T Scalar](d Getter, pointer string, def T) (val T, found bool, err error) {
+	raw, found, err := pGet(d, pointer, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = convert(d.convPolicy(), raw, def)
+	if err != nil {
+		err = withPointer(err, pointer)
+	}
+	return val, true, err
+}
+
+// get slice with item type T by JSON Pointer
+func PGetSlice[T Scalar](d Getter, pointer string, def []T) (val []T, found bool, err error) {
+	raw, found, err := pGet(d, pointer, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, idx, err := convertSlice[T](d.convPolicy(), raw)
+	if err != nil {
+		keyPath, _ := ParsePointer(pointer)
+		return def, true, withSliceIndex(err, keyPath, idx)
+	}
+	return val, true, nil
+}
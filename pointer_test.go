@@ -0,0 +1,121 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestParsePointer(t *testing.T) {
+	cases := []struct {
+		pointer string
+		keyPath []string
+		hasErr  bool
+	}{
+		{"", []string{}, false},
+		{"/", []string{""}, false},
+		{"/a/b", []string{"a", "b"}, false},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}, false},
+		{"/~01", []string{"~1"}, false},
+		{"a/b", nil, true},
+		{"/a~", nil, true},
+		{"/a~2", nil, true},
+	}
+	for _, c := range cases {
+		keyPath, err := jsonmap.ParsePointer(c.pointer)
+		if !reflect.DeepEqual(keyPath, c.keyPath) || bool(err != nil) != c.hasErr {
+			t.Fatalf("ParsePointer(%q) failed: got (%q, %v), expect (%q, hasErr:%v)", c.pointer, keyPath, err, c.keyPath, c.hasErr)
+		}
+		if err == nil && jsonmap.FormatPointer(keyPath) != c.pointer {
+			t.Fatalf("FormatPointer(%q) failed: got %q, expect %q", keyPath, jsonmap.FormatPointer(keyPath), c.pointer)
+		}
+	}
+}
+
+func TestPGet(t *testing.T) {
+	data := `{"spec":{"containers":[{"image":"nginx","ports":[80,443]}]}, "a/b":1, "m~n":2}`
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(data), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v, string = %v", err, useNumber, data)
+		}
+		if v, f, e := jsonmap.PGet(jm, "/spec/containers/0/image", ""); v != "nginx" || !f || e != nil {
+			t.Fatalf("PGet[string] failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jsonmap.PGet(jm, "/a~1b", 0); v != 1 || !f || e != nil {
+			t.Fatalf("PGet[int] failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jsonmap.PGet(jm, "/m~0n", 0); v != 2 || !f || e != nil {
+			t.Fatalf("PGet[int] failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jsonmap.PGet(jm, "/spec/containers/1/image", "def"); v != "def" || f || e != nil {
+			t.Fatalf("PGet[string] failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jsonmap.PGetSlice(jm, "/spec/containers/0/ports", []int{}); len(v) != 2 || !f || e != nil {
+			t.Fatalf("PGetSlice[int] failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.PGetSubMap("", nil); len(v) != 3 || !f || e != nil {
+			t.Fatalf("PGetSubMap failed: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.PGetAny("/spec/containers/0/ports/1", float64(0)); v != float64(443) || !f || e != nil {
+			t.Fatalf("PGetAny failed: got (%v, %v, %v)", v, f, e)
+		}
+		// indexes are strict, though RGet accepts them
+		for _, pointer := range []string{"/spec/containers/0/ports/-1", "/spec/containers/0/ports/+1", "/spec/containers/0/ports/01"} {
+			if _, f, e := jm.PGet(pointer, nil); f || e == nil || !strings.Contains(e.Error(), `"/spec/containers/0/ports"`) {
+				t.Fatalf("PGet(%q) should fail: got (%v, %v)", pointer, f, e)
+			}
+		}
+		// an index too large for int is out of range
+		if v, f, e := jm.PGet("/spec/containers/0/ports/99999999999999999999", nil); v != nil || f || e != nil {
+			t.Fatalf("PGet of a huge index should be not found: got (%v, %v, %v)", v, f, e)
+		}
+		if v, f, e := jm.RGetInt([]string{"spec", "containers", "0", "ports", "-1"}, 0); v != 443 || !f || e != nil {
+			t.Fatalf("RGetInt failed: got (%v, %v, %v)", v, f, e)
+		}
+		p, _ := jsonmap.CompilePointer("/spec/containers/0/ports/-1")
+		if _, f, e := p.Int(jm, 0); f || e == nil {
+			t.Fatalf("compiled pointer should be strict: got (%v, %v)", f, e)
+		}
+		if _, _, e := jm.PGet("/spec/containers/0/image/x", nil); e == nil || !strings.Contains(e.Error(), `"/spec/containers/0/image"`) {
+			t.Fatalf("PGet failed: got error %v", e)
+		}
+		if _, _, e := jm.PGet("/spec/containers/-", nil); e == nil || !strings.Contains(e.Error(), `"/spec/containers"`) {
+			t.Fatalf("PGet failed: got error %v", e)
+		}
+	}
+
+	// the same strict indexes for other getters
+	lz, _ := jsonmap.NewLazyJsonMap([]byte(data))
+	om, _ := jsonmap.UnmarshalOrdered([]byte(data))
+	v, _ := jsonmap.UnmarshalValue([]byte(data))
+	view, _ := jsonmap.UnmarshalWithOptions([]byte(data))
+	for _, d := range []jsonmap.Getter{lz, om, v, view} {
+		if i, f, e := jsonmap.PGet(d, "/spec/containers/0/ports/1", 0); i != 443 || !f || e != nil {
+			t.Fatalf("PGet of %T failed: got (%v, %v, %v)", d, i, f, e)
+		}
+		if _, f, e := jsonmap.PGet(d, "/spec/containers/0/ports/-1", 0); f || !errors.Is(e, jsonmap.ErrInvalidIndex) {
+			t.Fatalf("PGet of %T should fail: got (%v, %v)", d, f, e)
+		}
+		if _, f, e := jsonmap.PGet(d, "/spec/containers/0/ports/99999999999999999999", 0); f || e != nil {
+			t.Fatalf("PGet of a huge index of %T should be not found: got (%v, %v)", d, f, e)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+// FractionPolicy decides how to convert a fractional number to integer types
+type FractionPolicy int
+
+const (
+	// drop the fractional part, e.g. 1.7 -> 1, -1.7 -> -1, it's the default
+	Truncate FractionPolicy = iota
+	// return def and an error of ErrFraction
+	RejectFraction
+	// round half away from zero, e.g. 1.5 -> 2, -1.5 -> -2
+	Round
+)
+
+func (p FractionPolicy) String() string {
+	switch p {
+	case Truncate:
+		return "Truncate"
+	case RejectFraction:
+		return "RejectFraction"
+	case Round:
+		return "Round"
+	}
+	return "FractionPolicy(?)"
+}
+
+// ConvPolicy is the rules to convert raw json values to typed values, the zero value is the default policy.
+//
+// Numbers are converted the same no matter they are decoded as float64 (useNumber=false) or
+// json.Number (useNumber=true), except that json.Number keeps precision of big integers.
+// If conversion fails, getters always return def and an error.
+//
+// Coerce enables lenient conversion, which is off by default:
+// strings in json number syntax are parsed to numbers, strings like "true" or "0" are parsed to bools
+// by strconv.ParseBool, and numbers and bools are formatted to strings, json.Number keeps its original text.
+// Slice getters coerce each item.
+type ConvPolicy struct {
+	Fraction FractionPolicy
+	Coerce   bool
+}
+
+var defaultConvPolicy = ConvPolicy{}
@@ -0,0 +1,789 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+//// JSONPath query (a subset of RFC 9535)
+//// supported: $, @, .name, ['name'], .*, [*], [1], [-1], [start:end:step], [a,b], ..name, ..*, ..[sel],
+//// and filters like [?@.qty > 2 && @.name != 'x'] or [?(@.tag)] with ==, !=, <, <=, >, >=, &&, ||, !
+
+// QueryResult is a node matched by a JSONPath query
+type QueryResult struct {
+	KeyPath []string // concrete key path of the node, array index is in decimal
+	Value   interface{}
+}
+
+// the concrete path of the node as JSON Pointer
+func (r QueryResult) Pointer() string {
+	return FormatPointer(r.KeyPath)
+}
+
+// Query is a compiled JSONPath, it's immutable and safe for concurrent use
+type Query struct {
+	expr     string
+	segments []querySegment
+}
+
+func CompileQuery(expr string) (*Query, error) {
+	p := &queryParser{expr: expr}
+	p.skipSpace()
+	if !p.consume("$") {
+		return nil, p.errorf("expect '$'")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected %q", p.expr[p.pos])
+	}
+	return &Query{expr: expr, segments: segments}, nil
+}
+
+// like CompileQuery but panics if failed, it's used to initialize global variables
+func MustCompileQuery(expr string) *Query {
+	q, err := CompileQuery(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func (q *Query) String() string {
+	return q.expr
+}
+
+// returns all matched nodes, objects' members are visited in order of sorted keys
+func (q *Query) Select(d JsonMap) []QueryResult {
+	return q.selectFrom(map[string]interface{}(d))
+}
+
+func (q *Query) selectFrom(root interface{}) []QueryResult {
+	nodes := evalSegments(q.segments, root, queryNode{[]string{}, root})
+	ret := make([]QueryResult, 0, len(nodes))
+	for _, n := range nodes {
+		ret = append(ret, QueryResult{KeyPath: n.keyPath, Value: n.v})
+	}
+	return ret
+}
+
+// compile and evaluate a JSONPath
+func (d JsonMap) Query(expr string) ([]QueryResult, error) {
+	q, err := CompileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Select(d), nil
+}
+
+// select values with type T by JSONPath, it fails if any matched value can't be converted to T
+func Select[T Scalar](d Getter, expr string) (val []T, err error) {
+	q, err := CompileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	var zero T
+	root, _, _ := d.lookup(nil)
+	nodes := q.selectFrom(root)
+	val = make([]T, 0, len(nodes))
+	for _, n := range nodes {
+		v, e := convert(d.convPolicy(), n.Value, zero)
+		if e != nil {
+			return nil, withPath(e, n.KeyPath)
+		}
+		val = append(val, v)
+	}
+	return val, nil
+}
+
+//// select by JSONPath, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (d JsonMap) QueryStrings(expr string) (val []string, err error) {
+	return Select[string](d, expr)
+}
+
+func (d JsonMap) QueryBools(expr string) (val []bool, err error) {
+	return Select[bool](d, expr)
+}
+
+func (d JsonMap) QueryFloat64s(expr string) (val []float64, err error) {
+	return Select[float64](d, expr)
+}
+
+func (d JsonMap) QueryFloat32s(expr string) (val []float32, err error) {
+	return Select[float32](d, expr)
+}
+
+func (d JsonMap) QueryInt64s(expr string) (val []int64, err error) {
+	return Select[int64](d, expr)
+}
+
+func (d JsonMap) QueryUint64s(expr string) (val []uint64, err error) {
+	return Select[uint64](d, expr)
+}
+
+func (d JsonMap) QueryInt32s(expr string) (val []int32, err error) {
+	return Select[int32](d, expr)
+}
+
+func (d JsonMap) QueryUint32s(expr string) (val []uint32, err error) {
+	return Select[uint32](d, expr)
+}
+
+func (d JsonMap) QueryInts(expr string) (val []int, err error) {
+	return Select[int](d, expr)
+}
+
+func (d JsonMap) QueryUints(expr string) (val []uint, err error) {
+	return Select[uint](d, expr)
+}
+
+//// evaluation
+
+type queryNode struct {
+	keyPath []string
+	v       interface{}
+}
+
+// child node, the key path is copied so that sibling nodes don't share the backing array
+func (n queryNode) child(key string, v interface{}) queryNode {
+	keyPath := make([]string, len(n.keyPath)+1)
+	copy(keyPath, n.keyPath)
+	keyPath[len(n.keyPath)] = key
+	return queryNode{keyPath, v}
+}
+
+// visit children of a map in order of sorted keys, or children of an array in order
+func (n queryNode) eachChild(f func(c queryNode)) {
+	switch v := n.v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f(n.child(k, v[k]))
+		}
+	case []interface{}:
+		for i, item := range v {
+			f(n.child(strconv.Itoa(i), item))
+		}
+	}
+}
+
+// visit the node itself and all its descendants in pre-order
+func (n queryNode) eachDescendant(f func(c queryNode)) {
+	f(n)
+	n.eachChild(func(c queryNode) {
+		c.eachDescendant(f)
+	})
+}
+
+type querySegment struct {
+	descendant bool
+	selectors  []querySelector
+}
+
+func evalSegments(segments []querySegment, root interface{}, start queryNode) []queryNode {
+	nodes := []queryNode{start}
+	for _, seg := range segments {
+		var out []queryNode
+		for _, n := range nodes {
+			if seg.descendant {
+				n.eachDescendant(func(c queryNode) {
+					for _, sel := range seg.selectors {
+						out = sel.apply(root, c, out)
+					}
+				})
+			} else {
+				for _, sel := range seg.selectors {
+					out = sel.apply(root, n, out)
+				}
+			}
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+// a singular query selects at most one node, only names and indexes are used
+func isSingular(segments []querySegment) bool {
+	for _, seg := range segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type querySelector interface {
+	// append nodes selected from n to out
+	apply(root interface{}, n queryNode, out []queryNode) []queryNode
+}
+
+type nameSelector string
+
+func (s nameSelector) apply(root interface{}, n queryNode, out []queryNode) []queryNode {
+	if m, ok := n.v.(map[string]interface{}); ok {
+		if v, found := m[string(s)]; found {
+			out = append(out, n.child(string(s), v))
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (s wildcardSelector) apply(root interface{}, n queryNode, out []queryNode) []queryNode {
+	n.eachChild(func(c queryNode) {
+		out = append(out, c)
+	})
+	return out
+}
+
+type indexSelector int
+
+func (s indexSelector) apply(root interface{}, n queryNode, out []queryNode) []queryNode {
+	if a, ok := n.v.([]interface{}); ok {
+		if i, ok := arrayIndex(int(s), len(a)); ok {
+			out = append(out, n.child(strconv.Itoa(i), a[i]))
+		}
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) apply(root interface{}, n queryNode, out []queryNode) []queryNode {
+	a, ok := n.v.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := len(a)
+	normalize := func(i int) int {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+	clamp := func(i, lower, upper int) int {
+		if i < lower {
+			return lower
+		}
+		if i > upper {
+			return upper
+		}
+		return i
+	}
+	if s.step > 0 {
+		start, end := 0, length
+		if s.start != nil {
+			start = clamp(normalize(*s.start), 0, length)
+		}
+		if s.end != nil {
+			end = clamp(normalize(*s.end), 0, length)
+		}
+		for i := start; i < end; i += s.step {
+			out = append(out, n.child(strconv.Itoa(i), a[i]))
+			// i + step may overflow
+			if s.step >= end-i {
+				break
+			}
+		}
+	} else {
+		start, end := length-1, -1
+		if s.start != nil {
+			start = clamp(normalize(*s.start), -1, length-1)
+		}
+		if s.end != nil {
+			end = clamp(normalize(*s.end), -1, length-1)
+		}
+		for i := start; i > end; i += s.step {
+			out = append(out, n.child(strconv.Itoa(i), a[i]))
+			if s.step <= end-i {
+				break
+			}
+		}
+	}
+	return out
+}
+
+type filterSelector struct {
+	cond filterExpr
+}
+
+func (s filterSelector) apply(root interface{}, n queryNode, out []queryNode) []queryNode {
+	n.eachChild(func(c queryNode) {
+		if s.cond.test(root, c.v) {
+			out = append(out, c)
+		}
+	})
+	return out
+}
+
+//// filter expressions
+
+type filterExpr interface {
+	test(root, current interface{}) bool
+}
+
+type orExpr []filterExpr
+
+func (e orExpr) test(root, current interface{}) bool {
+	for _, sub := range e {
+		if sub.test(root, current) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []filterExpr
+
+func (e andExpr) test(root, current interface{}) bool {
+	for _, sub := range e {
+		if !sub.test(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	sub filterExpr
+}
+
+func (e notExpr) test(root, current interface{}) bool {
+	return !e.sub.test(root, current)
+}
+
+// test whether the query selects any node
+type existExpr struct {
+	q queryOperand
+}
+
+func (e existExpr) test(root, current interface{}) bool {
+	return len(e.q.eval(root, current)) > 0
+}
+
+type compareExpr struct {
+	op          string
+	left, right filterOperand
+}
+
+func (e compareExpr) test(root, current interface{}) bool {
+	a, aok := e.left.value(root, current)
+	b, bok := e.right.value(root, current)
+	switch e.op {
+	case "==":
+		return queryEqual(a, aok, b, bok)
+	case "!=":
+		return !queryEqual(a, aok, b, bok)
+	case "<":
+		return queryLess(a, aok, b, bok)
+	case "<=":
+		return queryLess(a, aok, b, bok) || queryEqual(a, aok, b, bok)
+	case ">":
+		return queryLess(b, bok, a, aok)
+	case ">=":
+		return queryLess(b, bok, a, aok) || queryEqual(a, aok, b, bok)
+	}
+	return false
+}
+
+type filterOperand interface {
+	// returns the value and whether it exists
+	value(root, current interface{}) (interface{}, bool)
+}
+
+type literalOperand struct {
+	v interface{}
+}
+
+func (o literalOperand) value(root, current interface{}) (interface{}, bool) {
+	return o.v, true
+}
+
+// a relative query starts from @, or an absolute query starts from $
+type queryOperand struct {
+	absolute bool
+	segments []querySegment
+}
+
+func (o queryOperand) eval(root, current interface{}) []queryNode {
+	start := current
+	if o.absolute {
+		start = root
+	}
+	return evalSegments(o.segments, root, queryNode{nil, start})
+}
+
+func (o queryOperand) value(root, current interface{}) (interface{}, bool) {
+	nodes := o.eval(root, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].v, true
+}
+
+func queryNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func queryEqual(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return aok == bok
+	}
+	if fa, ok := queryNumber(a); ok {
+		fb, ok := queryNumber(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func queryLess(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return false
+	}
+	if fa, ok := queryNumber(a); ok {
+		fb, ok := queryNumber(b)
+		return ok && fa < fb
+	}
+	if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		return ok && sa < sb
+	}
+	return false
+}
+
+//// parser
+
+type queryParser struct {
+	expr string
+	pos  int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query %q syntax error at offset %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t' ||
+		p.expr[p.pos] == '\n' || p.expr[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *queryParser) peek(s string) bool {
+	return len(p.expr)-p.pos >= len(s) && p.expr[p.pos:p.pos+len(s)] == s
+}
+
+func (p *queryParser) consume(s string) bool {
+	if p.peek(s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// parse segments until no more '.' or '['
+func (p *queryParser) parseSegments() ([]querySegment, error) {
+	var segments []querySegment
+	for {
+		save := p.pos
+		p.skipSpace()
+		var seg querySegment
+		var err error
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek("[") {
+				seg.selectors, err = p.parseBracket()
+			} else {
+				seg.selectors, err = p.parseDotSelector()
+			}
+		case p.consume("."):
+			seg.selectors, err = p.parseDotSelector()
+		case p.peek("["):
+			seg.selectors, err = p.parseBracket()
+		default:
+			p.pos = save
+			return segments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+}
+
+// parse `*` or a member name after '.' or '..'
+func (p *queryParser) parseDotSelector() ([]querySelector, error) {
+	if p.consume("*") {
+		return []querySelector{wildcardSelector{}}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && isNameChar(p.expr[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expect member name or '*'")
+	}
+	return []querySelector{nameSelector(p.expr[start:p.pos])}, nil
+}
+
+// parse `[selector, selector, ...]`
+func (p *queryParser) parseBracket() ([]querySelector, error) {
+	p.consume("[")
+	var selectors []querySelector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return selectors, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expect ',' or ']'")
+		}
+	}
+}
+
+func (p *queryParser) parseSelector() (querySelector, error) {
+	switch {
+	case p.peek("'") || p.peek(`"`):
+		s, err := p.parseString()
+		return nameSelector(s), err
+	case p.consume("*"):
+		return wildcardSelector{}, nil
+	case p.consume("?"):
+		p.skipSpace()
+		cond, err := p.parseOr()
+		return filterSelector{cond}, err
+	}
+	// index or slice
+	var nums [3]*int
+	for i := 0; i < 3; i++ {
+		p.skipSpace()
+		if n, ok, err := p.parseInt(); err != nil {
+			return nil, err
+		} else if ok {
+			nums[i] = &n
+		}
+		p.skipSpace()
+		if i == 2 || !p.consume(":") {
+			if i == 0 {
+				if nums[0] == nil {
+					return nil, p.errorf("expect selector")
+				}
+				return indexSelector(*nums[0]), nil
+			}
+			break
+		}
+	}
+	s := sliceSelector{start: nums[0], end: nums[1], step: 1}
+	if nums[2] != nil {
+		s.step = *nums[2]
+	}
+	return s, nil
+}
+
+func (p *queryParser) parseInt() (n int, ok bool, err error) {
+	start := p.pos
+	if p.pos < len(p.expr) && p.expr[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false, nil
+	}
+	n, err = strconv.Atoi(p.expr[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false, p.errorf("invalid integer %q", p.expr[start:p.pos])
+	}
+	return n, true, nil
+}
+
+func (p *queryParser) parseString() (string, error) {
+	s, end, err := scanQuoted(p.expr, p.pos)
+	if err != nil {
+		return "", p.errorf("%v", err)
+	}
+	p.pos = end
+	return s, nil
+}
+
+func (p *queryParser) parseOr() (filterExpr, error) {
+	var subs orExpr
+	for {
+		sub, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return subs, nil
+}
+
+func (p *queryParser) parseAnd() (filterExpr, error) {
+	var subs andExpr
+	for {
+		sub, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return subs, nil
+}
+
+func (p *queryParser) parseBasic() (filterExpr, error) {
+	if p.consume("!") && !p.peek("=") {
+		p.skipSpace()
+		sub, err := p.parseBasic()
+		return notExpr{sub}, err
+	}
+	if p.consume("(") {
+		p.skipSpace()
+		sub, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expect ')'")
+		}
+		return sub, nil
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.consume(op) {
+			continue
+		}
+		p.skipSpace()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range []filterOperand{left, right} {
+			if q, ok := o.(queryOperand); ok && !isSingular(q.segments) {
+				return nil, p.errorf("non-singular query is not comparable")
+			}
+		}
+		return compareExpr{op, left, right}, nil
+	}
+	q, ok := left.(queryOperand)
+	if !ok {
+		return nil, p.errorf("expect comparison operator")
+	}
+	return existExpr{q}, nil
+}
+
+func (p *queryParser) parseOperand() (filterOperand, error) {
+	switch {
+	case p.peek("@") || p.peek("$"):
+		absolute := p.expr[p.pos] == '$'
+		p.pos++
+		segments, err := p.parseSegments()
+		return queryOperand{absolute, segments}, err
+	case p.peek("'") || p.peek(`"`):
+		s, err := p.parseString()
+		return literalOperand{s}, err
+	case p.consume("true"):
+		return literalOperand{true}, nil
+	case p.consume("false"):
+		return literalOperand{false}, nil
+	case p.consume("null"):
+		return literalOperand{nil}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && (p.expr[p.pos] == '-' || p.expr[p.pos] == '+' || p.expr[p.pos] == '.' ||
+		p.expr[p.pos] == 'e' || p.expr[p.pos] == 'E' || (p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9')) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expect operand")
+	}
+	f, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+	return literalOperand{f}, nil
+}
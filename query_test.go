@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+const jsonStrForQueryTest = `{
+	"id": 0,
+	"store": {"name": "s", "id": 1},
+	"items": [
+		{"id": 10, "name": "apple", "price": 1.5, "qty": 3, "tags": ["fruit"]},
+		{"id": 11, "name": "pen", "price": 2, "qty": 1},
+		{"id": 12, "name": "book", "price": 12.25, "qty": 5, "tags": []}
+	]
+}`
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		expr     string
+		pointers []string
+	}{
+		{"$", []string{""}},
+		{"$.items[*].price", []string{"/items/0/price", "/items/1/price", "/items/2/price"}},
+		{"$.items[-1].name", []string{"/items/2/name"}},
+		{"$['store']['name', 'id']", []string{"/store/name", "/store/id"}},
+		{"$.store.*", []string{"/store/id", "/store/name"}},
+		{"$..id", []string{"/id", "/items/0/id", "/items/1/id", "/items/2/id", "/store/id"}},
+		{"$.items[0:2].id", []string{"/items/0/id", "/items/1/id"}},
+		{"$.items[::-1].id", []string{"/items/2/id", "/items/1/id", "/items/0/id"}},
+		{"$.items[1:].id", []string{"/items/1/id", "/items/2/id"}},
+		{"$.items[1::9223372036854775807].id", []string{"/items/1/id"}},
+		{"$.items[::-9223372036854775808].id", []string{"/items/2/id"}},
+		{"$.items[-1::-9223372036854775807].id", []string{"/items/2/id"}},
+		{"$.items[?(@.qty > 2)].name", []string{"/items/0/name", "/items/2/name"}},
+		{"$.items[?@.qty >= 3 && @.price < 2].name", []string{"/items/0/name"}},
+		{"$.items[?@.name == 'pen' || @.id == 12].id", []string{"/items/1/id", "/items/2/id"}},
+		{"$.items[?@.tags].id", []string{"/items/0/id", "/items/2/id"}},
+		{"$.items[?!@.tags].id", []string{"/items/1/id"}},
+		{"$.items[?@.qty == $.items[1].qty].id", []string{"/items/1/id"}},
+		{"$.items[5]", nil},
+		{"$.none..id", nil},
+	}
+	for _, useNumber := range []bool{false, true} {
+		jm, err := jsonmap.Unmarshal([]byte(jsonStrForQueryTest), useNumber)
+		if err != nil {
+			t.Fatalf("jsonmap.Unmarshal failed: %v. useNumber = %v", err, useNumber)
+		}
+		for _, c := range cases {
+			ret, err := jm.Query(c.expr)
+			var pointers []string
+			for _, r := range ret {
+				pointers = append(pointers, r.Pointer())
+			}
+			if err != nil || !reflect.DeepEqual(pointers, c.pointers) {
+				t.Fatalf("Query(%q) failed: got (%q, %v), expect %q, useNumber = %v", c.expr, pointers, err, c.pointers, useNumber)
+			}
+		}
+
+		if v, err := jm.QueryFloat64s("$.items[*].price"); err != nil || !reflect.DeepEqual(v, []float64{1.5, 2, 12.25}) {
+			t.Fatalf("QueryFloat64s failed: got (%v, %v)", v, err)
+		}
+		if v, err := jm.QueryInts("$..qty"); err != nil || !reflect.DeepEqual(v, []int{3, 1, 5}) {
+			t.Fatalf("QueryInts failed: got (%v, %v)", v, err)
+		}
+		if v, err := jm.QueryStrings("$.items[*].price"); err == nil || v != nil {
+			t.Fatalf("QueryStrings failed: got (%v, %v)", v, err)
+		}
+	}
+	for _, expr := range []string{"", "items", "$.", "$[", "$['a'", "$[a]", "$.items[?@.qty >]", "$.items[?@..id == 1]", "$.items[?@.id == $.store.id + 1]", "$.a b"} {
+		if _, err := jsonmap.CompileQuery(expr); err == nil {
+			t.Fatalf("CompileQuery(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// SetOption configures RSet
+type SetOption func(*setOptions)
+
+type setOptions struct {
+	createArrays bool
+	force        bool
+}
+
+// create an array for a missing node if its key is a non-negative integer, and append to arrays by the index of the end.
+// Without it missing nodes are always maps and the index must be in range. An index beyond the end is still out of range,
+// so a huge index in key path can't allocate a huge array.
+func CreateArrays() SetOption {
+	return func(o *setOptions) { o.createArrays = true }
+}
+
+// overwrite intermediate values which can't be stepped into, e.g. a string or an array with a non-integer key,
+// with new nodes, instead of returning an error
+func Force() SetOption {
+	return func(o *setOptions) { o.force = true }
+}
+
+// set value of key, d must not be nil. val is converted to decoded json shapes by the same rule as RSet.
+func (d JsonMap) Set(key string, val interface{}) error {
+	v, err := normalizeValue(val)
+	if err != nil {
+		return err
+	}
+	d[key] = v
+	return nil
+}
+
+// set value at keyPath, missing or null intermediate nodes are created as maps, arrays are stepped into by index.
+// It returns a *PathError if an intermediate value is a scalar unless Force, or an index is out of range,
+// and d is not changed on error. d must not be nil.
+//
+// val is converted to the shapes decoded by SmartNumbers so getters can read it back: integers to int64 or uint64,
+// floats to float64, slices and arrays to []interface{}, maps and JsonMap to map[string]interface{}, recursively.
+// Other types like structs are converted by a json round trip. Maps and slices are copied.
+func (d JsonMap) RSet(keyPath []string, val interface{}, opts ...SetOption) error {
+	if len(keyPath) == 0 {
+		return emptyPathError()
+	}
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	v, err := normalizeValue(val)
+	if err != nil {
+		return err
+	}
+	_, err = o.setIn(map[string]interface{}(d), keyPath, 0, v)
+	return err
+}
+
+// set val at keyPath[idx:] in v, which is the value at keyPath[0:idx], return the new value at keyPath[0:idx].
+// Nothing is changed on error, since only new nodes are created before the last step.
+func (o *setOptions) setIn(v interface{}, keyPath []string, idx int, val interface{}) (interface{}, error) {
+	if idx == len(keyPath) {
+		return val, nil
+	}
+	key := keyPath[idx]
+	switch t := v.(type) {
+	case map[string]interface{}:
+		nv, err := o.setIn(t[key], keyPath, idx+1, val)
+		if err != nil {
+			return v, err
+		}
+		t[key] = nv
+		return t, nil
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil {
+			if !o.force {
+				return v, lookupError(keyPath, idx, v)
+			}
+			break
+		}
+		j, ok := arrayIndex(i, len(t))
+		if !ok && o.createArrays && i == len(t) {
+			t = append(t, nil)
+			ok = true
+		}
+		if !ok {
+			return v, &PathError{Path: copyKeyPath(keyPath), Index: idx, Err: ErrIndexOutOfRange}
+		}
+		nv, err := o.setIn(t[j], keyPath, idx+1, val)
+		if err != nil {
+			return v, err
+		}
+		t[j] = nv
+		return t, nil
+	default:
+		if v != nil && !o.force {
+			return v, &PathError{Path: copyKeyPath(keyPath), Index: idx - 1, Err: ErrNotMap, Expected: "map or array", Actual: typeName(v)}
+		}
+	}
+	var node interface{} = map[string]interface{}{}
+	if o.createArrays && isArrayIndex(key) {
+		node = []interface{}{}
+	}
+	return o.setIn(node, keyPath, idx, val)
+}
+
+// whether key is a non-negative decimal integer
+func isArrayIndex(key string) bool {
+	i, err := strconv.Atoi(key)
+	return err == nil && i >= 0
+}
+
+// convert v to decoded json shapes, see RSet
+func normalizeValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, bool, string, float64, int64, uint64, json.Number:
+		return v, nil
+	case JsonMap:
+		return normalizeValue(map[string]interface{}(t))
+	case json.Marshaler, encoding.TextMarshaler:
+		return jsonRoundTrip(v)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32:
+		// shortest text of float32, e.g. 0.1 rather than 0.10000000149011612
+		return strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			break // []byte is base64 string in json
+		}
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		a := make([]interface{}, rv.Len())
+		for i := range a {
+			item, err := normalizeValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			a[i] = item
+		}
+		return a, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for it := rv.MapRange(); it.Next(); {
+			item, err := normalizeValue(it.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			m[it.Key().String()] = item
+		}
+		return m, nil
+	}
+	return jsonRoundTrip(v)
+}
+
+// marshal v and decode it by SmartNumbers
+func jsonRoundTrip(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	o := decodeOptions{smartNumbers: true}
+	return o.unmarshalValue(b)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestRSet(t *testing.T) {
+	jm := jsonmap.JsonMap{}
+	jm.Set("k", jsonmap.JsonMap{"x": 1})
+	if err := jm.RSet([]string{"k", "y"}, 2); err != nil || jm.String() != `{"k":{"x":1,"y":2}}` {
+		t.Fatalf("Set JsonMap failed: got (%s, %v)", jm, err)
+	}
+
+	jm = jsonmap.JsonMap{}
+	if err := jm.RSet([]string{"a", "b", "c"}, 1); err != nil || jm.String() != `{"a":{"b":{"c":1}}}` {
+		t.Fatalf("RSet failed: got (%s, %v)", jm, err)
+	}
+	if err := jm.RSet([]string{"a", "b", "d"}, "s"); err != nil || jm.String() != `{"a":{"b":{"c":1,"d":"s"}}}` {
+		t.Fatalf("RSet failed: got (%s, %v)", jm, err)
+	}
+	// numeric keys make maps by default
+	if err := jm.RSet([]string{"n", "0"}, true); err != nil || jm.String() != `{"a":{"b":{"c":1,"d":"s"}},"n":{"0":true}}` {
+		t.Fatalf("RSet failed: got (%s, %v)", jm, err)
+	}
+	if err := jm.RSet(nil, 1); !errors.Is(err, jsonmap.ErrEmptyPath) {
+		t.Fatalf("RSet empty path should fail: got %v", err)
+	}
+
+	// scalar intermediate
+	err := jm.RSet([]string{"a", "b", "c", "x"}, 2)
+	var pe *jsonmap.PathError
+	if !errors.As(err, &pe) || !errors.Is(err, jsonmap.ErrNotMap) || pe.Index != 2 ||
+		err.Error() != `jsonmap: key "/a/b/c/x": not map at "/a/b/c": got int64 but expected map or array` {
+		t.Fatalf("RSet through scalar should fail: got %v", err)
+	}
+	if err := jm.RSet([]string{"a", "b", "c", "x"}, 2, jsonmap.Force()); err != nil ||
+		jm.String() != `{"a":{"b":{"c":{"x":2},"d":"s"}},"n":{"0":true}}` {
+		t.Fatalf("RSet with Force failed: got (%s, %v)", jm, err)
+	}
+}
+
+func TestRSetArray(t *testing.T) {
+	jm, _ := jsonmap.Unmarshal([]byte(`{"arr":[1, {"x":null}], "nil":null}`), false)
+	if err := jm.RSet([]string{"arr", "-1", "x", "y"}, 1); err != nil || jm.String() != `{"arr":[1,{"x":{"y":1}}],"nil":null}` {
+		t.Fatalf("RSet into array failed: got (%s, %v)", jm, err)
+	}
+	if err := jm.RSet([]string{"arr", "0"}, 0); err != nil || jm.String() != `{"arr":[0,{"x":{"y":1}}],"nil":null}` {
+		t.Fatalf("RSet array item failed: got (%s, %v)", jm, err)
+	}
+	if err := jm.RSet([]string{"arr", "3"}, 3); !errors.Is(err, jsonmap.ErrIndexOutOfRange) ||
+		err.Error() != `jsonmap: key "/arr/3": index out of range` {
+		t.Fatalf("RSet out of range should fail: got %v", err)
+	}
+	if err := jm.RSet([]string{"arr", "2"}, 2, jsonmap.CreateArrays()); err != nil ||
+		jm.String() != `{"arr":[0,{"x":{"y":1}},2],"nil":null}` {
+		t.Fatalf("RSet extending array failed: got (%s, %v)", jm, err)
+	}
+	// only the end of array can be extended, a huge index doesn't allocate
+	for _, keyPath := range [][]string{{"arr", "4"}, {"arr", "3000000000"}, {"new", "3000000000"}, {"nil", "1"}} {
+		if err := jm.RSet(keyPath, 1, jsonmap.CreateArrays()); !errors.Is(err, jsonmap.ErrIndexOutOfRange) {
+			t.Fatalf("RSet %v should fail: got %v", keyPath, err)
+		}
+	}
+	if err := jm.RSet([]string{"nil", "0", "a"}, "a", jsonmap.CreateArrays()); err != nil ||
+		jm.String() != `{"arr":[0,{"x":{"y":1}},2],"nil":[{"a":"a"}]}` {
+		t.Fatalf("RSet creating array failed: got (%s, %v)", jm, err)
+	}
+	if err := jm.RSet([]string{"arr", "k"}, 1); !errors.Is(err, jsonmap.ErrInvalidIndex) ||
+		err.Error() != `jsonmap: key "/arr/k": invalid index at "/arr"` {
+		t.Fatalf("RSet array by key should fail: got %v", err)
+	}
+	if err := jm.RSet([]string{"arr", "k"}, 1, jsonmap.Force()); err != nil ||
+		jm.String() != `{"arr":{"k":1},"nil":[{"a":"a"}]}` {
+		t.Fatalf("RSet with Force failed: got (%s, %v)", jm, err)
+	}
+}
+
+func TestSetNormalizesValues(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		N    int    `json:"n"`
+	}
+	jm := jsonmap.JsonMap{}
+	_ = jm.Set("port", 8080)
+	if v, _, err := jm.GetInt64("port", 0); v != 8080 || err != nil {
+		t.Fatalf("GetInt64 of int failed: got (%v, %v)", v, err)
+	}
+	if v, _, err := jm.GetFloat64("port", 0); v != 8080 || err != nil {
+		t.Fatalf("GetFloat64 of int failed: got (%v, %v)", v, err)
+	}
+	if err := jm.RSet([]string{"a", "b"}, uint8(3)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, err := jm.RGetInt([]string{"a", "b"}, 0); v != 3 || err != nil {
+		t.Fatalf("RGetInt of uint8 failed: got (%v, %v)", v, err)
+	}
+	_ = jm.Set("f", float32(0.1))
+	_ = jm.Set("s", []string{"x", "y"})
+	if v, _, err := jm.GetSlice("s", nil); len(v) != 2 || err != nil {
+		t.Fatalf("GetSlice of []string failed: got (%v, %v)", v, err)
+	}
+	if v, _, err := jm.RGetStringSlice([]string{"s"}, nil); len(v) != 2 || v[1] != "y" || err != nil {
+		t.Fatalf("RGetStringSlice of []string failed: got (%v, %v)", v, err)
+	}
+	_ = jm.Set("m", map[string]interface{}{"j": jsonmap.JsonMap{"k": []int{1}}, "items": []item{{"x", 1}}})
+	if err := jm.RSet([]string{"m", "j", "l"}, true); err != nil {
+		t.Fatalf("RSet into nested JsonMap failed: %v", err)
+	}
+	if v, _, err := jm.RGetInt([]string{"m", "items", "0", "n"}, 0); v != 1 || err != nil {
+		t.Fatalf("RGetInt of struct field failed: got (%v, %v)", v, err)
+	}
+	expected := `{"a":{"b":3},"f":0.1,"m":{"items":[{"n":1,"name":"x"}],"j":{"k":[1],"l":true}},"port":8080,"s":["x","y"]}`
+	if s := jm.String(); s != expected {
+		t.Fatalf("Set failed: got %s", s)
+	}
+	if err := jm.Set("c", make(chan int)); err == nil {
+		t.Fatal("Set of channel should fail")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// max length of a line if MaxBytes is not set
+const defaultMaxLineBytes = 64 << 20
+
+// LineDecoder reads JSON Lines (NDJSON), each line is a json object. Blank lines are ignored.
+//
+//	d := jsonmap.NewLineDecoder(r)
+//	for d.Next() {
+//		id, _, _ := d.Map().GetInt64("id", 0)
+//	}
+//	if err := d.Err(); err != nil {
+//		...
+//	}
+type LineDecoder struct {
+	sc        *bufio.Scanner
+	opts      decodeOptions
+	maxLine   int
+	line      int
+	cur       JsonMap
+	err       error
+	skip      bool
+	collect   bool
+	malformed []*LineError
+}
+
+// options are applied to each line, MaxBytes limits the length of a line
+func NewLineDecoder(r io.Reader, opts ...Option) *LineDecoder {
+	d := &LineDecoder{sc: bufio.NewScanner(r), opts: *newDecodeOptions(opts)}
+	d.maxLine = defaultMaxLineBytes
+	if d.opts.maxBytes > 0 {
+		d.maxLine = d.opts.maxBytes
+	}
+	// a line is a token of Scanner, one more byte for the newline
+	d.sc.Buffer(make([]byte, 0, 4096), d.maxLine+1)
+	return d
+}
+
+// skip malformed lines instead of stopping at them, if collect is true they are recorded in Malformed
+func (d *LineDecoder) SkipMalformed(collect bool) {
+	d.skip, d.collect = true, collect
+}
+
+// advance to the next line, it returns false at the end of input or on error
+func (d *LineDecoder) Next() bool {
+	d.cur = nil
+	if d.err != nil {
+		return false
+	}
+	for d.sc.Scan() {
+		d.line++
+		data := d.sc.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		m, err := d.opts.unmarshal(data)
+		if err == nil {
+			d.cur = m
+			return true
+		}
+		le := &LineError{Line: d.line, Err: err}
+		if !d.skip {
+			d.err = le
+			return false
+		}
+		if d.collect {
+			d.malformed = append(d.malformed, le)
+		}
+	}
+	if err := d.sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = &LimitError{Limit: "MaxBytes", Max: d.maxLine, Offset: int64(d.maxLine)}
+		}
+		d.err = &LineError{Line: d.line + 1, Err: err}
+	}
+	return false
+}
+
+// the map of current line
+func (d *LineDecoder) Map() JsonMap {
+	return d.cur
+}
+
+// the map of current line with the policy set by WithConvPolicy
+func (d *LineDecoder) View() View {
+	return newView(d.cur, d.opts.policy)
+}
+
+// 1-based number of current line
+func (d *LineDecoder) Line() int {
+	return d.line
+}
+
+// the error stopping Next, it's a *LineError, nil at the end of input
+func (d *LineDecoder) Err() error {
+	return d.err
+}
+
+// malformed lines skipped, only recorded if SkipMalformed(true) is called
+func (d *LineDecoder) Malformed() []*LineError {
+	return d.malformed
+}
+
+// LineEncoder writes JsonMaps as JSON Lines (NDJSON)
+type LineEncoder struct {
+	enc *json.Encoder
+}
+
+func NewLineEncoder(w io.Writer) *LineEncoder {
+	return &LineEncoder{enc: json.NewEncoder(w)}
+}
+
+// whether to escape &, < and > in strings, it's true by default
+func (e *LineEncoder) SetEscapeHTML(on bool) {
+	e.enc.SetEscapeHTML(on)
+}
+
+// write d in one line, numbers in json.Number are written as is
+func (e *LineEncoder) Encode(d JsonMap) error {
+	return e.enc.Encode(map[string]interface{}(d))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestUnmarshalReader(t *testing.T) {
+	data := `{"a":{"b":7095620078347567873}}`
+	v, err := jsonmap.UnmarshalReader(strings.NewReader(data), jsonmap.UseNumber())
+	if i, f, e := v.RGetInt64([]string{"a", "b"}, 0); err != nil || i != 7095620078347567873 || !f || e != nil {
+		t.Fatalf("UnmarshalReader failed: got (%v, %v, %v), err = %v", i, f, e, err)
+	}
+	if _, err = jsonmap.UnmarshalReader(strings.NewReader(data), jsonmap.MaxBytes(10)); !errors.Is(err, jsonmap.ErrLimitExceeded) {
+		t.Fatalf("UnmarshalReader with MaxBytes failed: got %v", err)
+	}
+	if _, err = jsonmap.UnmarshalReader(strings.NewReader(data), jsonmap.MaxBytes(len(data))); err != nil {
+		t.Fatalf("UnmarshalReader within MaxBytes failed: got %v", err)
+	}
+}
+
+func TestLineDecoder(t *testing.T) {
+	data := "{\"id\":1}\n\n{\"id\":2}\r\n{\"id\":\n{\"id\":4, \"id\":5}\n[1]\n{\"id\":6}"
+	d := jsonmap.NewLineDecoder(strings.NewReader(data))
+	var ids []int
+	for d.Next() {
+		id, _, _ := d.Map().GetInt("id", 0)
+		ids = append(ids, id)
+	}
+	var le *jsonmap.LineError
+	if len(ids) != 2 || ids[1] != 2 || !errors.As(d.Err(), &le) || le.Line != 4 || d.Next() {
+		t.Fatalf("LineDecoder should stop at line 4: got %v, %v", ids, d.Err())
+	}
+
+	d = jsonmap.NewLineDecoder(strings.NewReader(data), jsonmap.DisallowDuplicateKeys())
+	d.SkipMalformed(true)
+	ids = nil
+	var lines []int
+	for d.Next() {
+		id, _, _ := d.View().GetInt("id", 0)
+		ids = append(ids, id)
+		lines = append(lines, d.Line())
+	}
+	if d.Err() != nil || len(ids) != 3 || ids[2] != 6 || lines[2] != 7 {
+		t.Fatalf("LineDecoder with SkipMalformed failed: got %v %v, %v", ids, lines, d.Err())
+	}
+	m := d.Malformed()
+	if len(m) != 3 || m[0].Line != 4 || m[1].Line != 5 || !errors.Is(m[1], jsonmap.ErrDuplicateKey) || m[2].Line != 6 {
+		t.Fatalf("LineDecoder Malformed failed: got %v", m)
+	}
+	if !strings.HasPrefix(m[1].Error(), `jsonmap: line 5: key "/id": duplicate key`) {
+		t.Fatalf("LineError.Error failed: got %s", m[1].Error())
+	}
+
+	// too long line
+	d = jsonmap.NewLineDecoder(strings.NewReader("{\"id\":1}\n{\"id\":12345}\n"), jsonmap.MaxBytes(10))
+	for d.Next() {
+	}
+	if !errors.Is(d.Err(), jsonmap.ErrLimitExceeded) || !errors.As(d.Err(), &le) || le.Line != 2 {
+		t.Fatalf("LineDecoder with MaxBytes failed: got %v", d.Err())
+	}
+}
+
+func TestLineEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := jsonmap.NewLineEncoder(&buf)
+	e.SetEscapeHTML(false)
+	jm, _ := jsonmap.Unmarshal([]byte(`{"a":"<b>", "n":7095620078347567873}`), true)
+	for i := 0; i < 2; i++ {
+		if err := e.Encode(jm); err != nil {
+			t.Fatalf("LineEncoder.Encode failed: %v", err)
+		}
+	}
+	line := `{"a":"<b>","n":7095620078347567873}` + "\n"
+	if buf.String() != line+line {
+		t.Fatalf("LineEncoder failed: got %s", buf.String())
+	}
+	d := jsonmap.NewLineDecoder(&buf, jsonmap.UseNumber())
+	for d.Next() {
+		if n, _, _ := d.Map().GetInt64("n", 0); n != 7095620078347567873 {
+			t.Fatalf("round trip failed: got %v", n)
+		}
+	}
+}
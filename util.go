@@ -18,6 +18,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 func DeepCopyMap(m map[string]interface{}) map[string]interface{} {
@@ -70,11 +72,50 @@ func JsonUnmarshalUseNumber(data []byte, v interface{}) error {
 }
 
 func Unmarshal(data []byte, useNumber bool) (jm JsonMap, err error) {
-	m := make(map[string]interface{})
-	if useNumber {
-		err = JsonUnmarshalUseNumber(data, &m)
-	} else {
-		err = json.Unmarshal(data, &m)
+	var o decodeOptions
+	o.useNumber = useNumber
+	return o.unmarshal(data)
+}
+
+// decode numbers in "smart numbers" mode: integers that fit in int64 or uint64 are decoded as int64 or uint64 exactly,
+// other numbers are decoded as float64, except numbers out of range of float64 or integers beyond uint64 are kept as
+// json.Number, so no number loses precision silently
+func UnmarshalSmartNumbers(data []byte) (jm JsonMap, err error) {
+	var o decodeOptions
+	o.smartNumbers = true
+	return o.unmarshal(data)
+}
+
+// replace json.Number in v by smart numbers in place
+func smartNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, i := range t {
+			t[k] = smartNumbers(i)
+		}
+	case []interface{}:
+		for k, i := range t {
+			t[k] = smartNumbers(i)
+		}
+	case json.Number:
+		return smartNumber(t)
+	}
+	return v
+}
+
+func smartNumber(n json.Number) interface{} {
+	s := string(n)
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
 	}
-	return m, err
+	return n
 }
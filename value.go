@@ -0,0 +1,326 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JsonValue is any json value: object, array, string, number, bool or null.
+// It's navigated by Key, Index and At, and converted by typed methods with the same rules as JsonMap.
+// A missing value, e.g. Index out of range, is a JsonValue which is not found, typed methods return def for it.
+type JsonValue struct {
+	v      interface{}
+	found  bool
+	policy ConvPolicy
+}
+
+// wrap a decoded json value, e.g. map[string]interface{}, []interface{}, float64 or json.Number
+func NewValue(v interface{}) JsonValue {
+	if m, ok := v.(JsonMap); ok {
+		v = map[string]interface{}(m)
+	}
+	return JsonValue{v: v, found: true}
+}
+
+// the whole map as a JsonValue
+func (d JsonMap) Value() JsonValue {
+	return NewValue(d)
+}
+
+// the whole map as a JsonValue with the same policy
+func (v View) Value() JsonValue {
+	return NewValue(v.Map()).WithPolicy(v.Policy())
+}
+
+func (v JsonValue) WithPolicy(p ConvPolicy) JsonValue {
+	v.policy = p
+	return v
+}
+
+func (v JsonValue) Policy() ConvPolicy {
+	return v.policy
+}
+
+// the underlying value, nil if not found
+func (v JsonValue) Raw() interface{} {
+	return v.v
+}
+
+// compact json without HTML escaping, a missing value is null
+func (v JsonValue) String() string {
+	b, err := v.Marshal(EscapeHTML(false))
+	if err != nil {
+		// e.g. NaN can't be marshalled
+		return fmt.Sprintf("JsonValue(%v)", v.v)
+	}
+	return string(b)
+}
+
+func (v JsonValue) Found() bool {
+	return v.found
+}
+
+func (v JsonValue) IsNull() bool {
+	return v.found && v.v == nil
+}
+
+func (v JsonValue) IsMap() bool {
+	_, ok := v.v.(map[string]interface{})
+	return ok
+}
+
+func (v JsonValue) IsArray() bool {
+	_, ok := v.v.([]interface{})
+	return ok
+}
+
+// the value as JsonMap if it's an object
+func (v JsonValue) Map() (JsonMap, bool) {
+	m, ok := v.v.(map[string]interface{})
+	return m, ok
+}
+
+// the value as a View with the same policy if it's an object
+func (v JsonValue) View() (View, bool) {
+	m, ok := v.v.(map[string]interface{})
+	return newView(m, v.policy), ok
+}
+
+// the value as array if it's an array
+func (v JsonValue) Array() ([]interface{}, bool) {
+	a, ok := v.v.([]interface{})
+	return a, ok
+}
+
+// length of array or object, 0 for others
+func (v JsonValue) Len() int {
+	switch t := v.v.(type) {
+	case []interface{}:
+		return len(t)
+	case map[string]interface{}:
+		return len(t)
+	}
+	return 0
+}
+
+//// navigation, a missing value is returned if the step fails
+
+// member of object
+func (v JsonValue) Key(key string) JsonValue {
+	if m, ok := v.v.(map[string]interface{}); ok {
+		if i, ok := m[key]; ok {
+			return v.child(i)
+		}
+	}
+	return JsonValue{policy: v.policy}
+}
+
+// item of array, negative index counts from the end
+func (v JsonValue) Index(i int) JsonValue {
+	if a, ok := v.v.([]interface{}); ok {
+		if i, ok := arrayIndex(i, len(a)); ok {
+			return v.child(a[i])
+		}
+	}
+	return JsonValue{policy: v.policy}
+}
+
+// value at keyPath, keys step into arrays by index, same as RGet
+func (v JsonValue) At(keyPath ...string) JsonValue {
+	if i, found, err := v.lookup(keyPath); found && err == nil {
+		return v.child(i)
+	}
+	return JsonValue{policy: v.policy}
+}
+
+func (v JsonValue) child(i interface{}) JsonValue {
+	return JsonValue{v: i, found: true, policy: v.policy}
+}
+
+// call fn for each item of array until it returns false
+func (v JsonValue) Each(fn func(i int, item JsonValue) bool) {
+	a, _ := v.v.([]interface{})
+	for i, item := range a {
+		if !fn(i, v.child(item)) {
+			return
+		}
+	}
+}
+
+// call fn for each member of object in order of sorted keys until it returns false
+func (v JsonValue) EachKey(fn func(key string, member JsonValue) bool) {
+	m, _ := v.v.(map[string]interface{})
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !fn(k, v.child(m[k])) {
+			return
+		}
+	}
+}
+
+// compile and evaluate a JSONPath on the value
+func (v JsonValue) Query(expr string) ([]QueryResult, error) {
+	q, err := CompileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.selectFrom(v.v), nil
+}
+
+//// JsonValue is a Getter, so it works with generic getters, e.g. RGet(v, keyPath, def)
+
+func (v JsonValue) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	if !v.found {
+		return nil, false, nil
+	}
+	return rGetIn(v.v, keyPath, 0, nil, false)
+}
+
+func (v JsonValue) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	if !v.found {
+		return nil, false, nil
+	}
+	return rGetIn(v.v, keyPath, 0, nil, true)
+}
+
+func (v JsonValue) lookupKey(key string) (val interface{}, found bool, err error) {
+	m, _ := v.v.(map[string]interface{})
+	val, found = m[key]
+	return
+}
+
+func (v JsonValue) convPolicy() ConvPolicy {
+	return v.policy
+}
+
+//// conversion, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+// convert the value to T, found is false if the value is missing
+func As[T Scalar](v JsonValue, def T) (val T, found bool, err error) {
+	if !v.found {
+		return def, false, nil
+	}
+	val, err = convert(v.policy, v.v, def)
+	return val, true, err
+}
+
+// convert an array to []T, idx of the failed item is in the error's Path
+func AsSlice[T Scalar](v JsonValue, def []T) (val []T, found bool, err error) {
+	if !v.found {
+		return def, false, nil
+	}
+	val, idx, err := convertSlice[T](v.policy, v.v)
+	if err != nil {
+		return def, true, withSliceIndex(err, nil, idx)
+	}
+	return val, true, nil
+}
+
+// this method ensures val’s type is same as def
+func (v JsonValue) Any(def interface{}) (val interface{}, found bool, err error) {
+	if !v.found {
+		return def, false, nil
+	}
+	val, err = v.policy.toAny(v.v, def)
+	return val, true, err
+}
+
+func (v JsonValue) Str(def string) (val string, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Bool(def bool) (val bool, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Float64(def float64) (val float64, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Float32(def float32) (val float32, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Int64(def int64) (val int64, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Uint64(def uint64) (val uint64, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Int32(def int32) (val int32, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Uint32(def uint32) (val uint32, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Int(def int) (val int, found bool, err error) {
+	return As(v, def)
+}
+
+func (v JsonValue) Uint(def uint) (val uint, found bool, err error) {
+	return As(v, def)
+}
+
+//// conversion of array, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (v JsonValue) StringSlice(def []string) (val []string, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) BoolSlice(def []bool) (val []bool, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Float64Slice(def []float64) (val []float64, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Float32Slice(def []float32) (val []float32, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Int64Slice(def []int64) (val []int64, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Uint64Slice(def []uint64) (val []uint64, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Int32Slice(def []int32) (val []int32, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) Uint32Slice(def []uint32) (val []uint32, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) IntSlice(def []int) (val []int, found bool, err error) {
+	return AsSlice(v, def)
+}
+
+func (v JsonValue) UintSlice(def []uint) (val []uint, found bool, err error) {
+	return AsSlice(v, def)
+}
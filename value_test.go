@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestJsonValue(t *testing.T) {
+	data := `[{"id":1, "tags":["a", "b"]}, {"id":"2", "price":1.5}, null, 7095620078347567873]`
+	for _, useNumber := range []bool{false, true} {
+		var opts []jsonmap.Option
+		if useNumber {
+			opts = append(opts, jsonmap.UseNumber())
+		}
+		v, err := jsonmap.UnmarshalValue([]byte(data), opts...)
+		if err != nil || !v.IsArray() || v.Len() != 4 {
+			t.Fatalf("UnmarshalValue failed: got (%v, %v)", v.Raw(), err)
+		}
+		if i, f, e := v.Index(0).Key("id").Int(0); i != 1 || !f || e != nil {
+			t.Fatalf("Index(0).Key(id).Int failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.Index(1).Key("id").Int(7); i != 7 || !f || !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("Int by string failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.Index(1).Key("id").WithPolicy(jsonmap.ConvPolicy{Coerce: true}).Int(7); i != 2 || !f || e != nil {
+			t.Fatalf("Coerce Int by string failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.Round}).At("1", "price").Int(0); i != 2 || !f || e != nil {
+			t.Fatalf("Round At(1, price).Int failed: got (%v, %v, %v)", i, f, e)
+		}
+		if s, f, e := v.Index(0).Key("tags").StringSlice(nil); !reflect.DeepEqual(s, []string{"a", "b"}) || !f || e != nil {
+			t.Fatalf("StringSlice failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := v.Index(-1).Str("def"); s != "def" || !f || !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("Str by number failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s := v.Index(0).Key("tags").String(); s != `["a","b"]` {
+			t.Fatalf("String failed: got %s", s)
+		}
+		if s := fmt.Sprint(v.Index(9), v.At("1", "price")); s != "null 1.5" {
+			t.Fatalf("String of missing value failed: got %s", s)
+		}
+		if !v.Index(2).IsNull() || !v.Index(2).Found() || v.Index(4).Found() || v.Index(4).IsNull() {
+			t.Fatal("null and missing values failed")
+		}
+		if i, f, e := v.Index(9).Key("x").Int(3); i != 3 || f || e != nil {
+			t.Fatalf("missing value failed: got (%v, %v, %v)", i, f, e)
+		}
+		if v.At("0", "tags", "x").Found() || v.Key("x").Found() || v.Index(0).Index(0).Found() {
+			t.Fatal("navigation on wrong types should return missing values")
+		}
+
+		// generic getters and queries work on JsonValue
+		if i, f, e := jsonmap.RGet(v, []string{"0", "id"}, 0); i != 1 || !f || e != nil {
+			t.Fatalf("RGet failed: got (%v, %v, %v)", i, f, e)
+		}
+		var pe *jsonmap.PathError
+		if _, _, e := jsonmap.RGet(v, []string{"0", "tags", "x"}, ""); !errors.Is(e, jsonmap.ErrInvalidIndex) || !errors.As(e, &pe) || pe.Index != 1 {
+			t.Fatalf("RGet error failed: got %v", e)
+		}
+		if ids, e := jsonmap.Select[int](v.WithPolicy(jsonmap.ConvPolicy{Coerce: true}), "$[*].id"); !reflect.DeepEqual(ids, []int{1, 2}) || e != nil {
+			t.Fatalf("Select failed: got (%v, %v)", ids, e)
+		}
+		if r, e := v.Query("$..tags[1]"); len(r) != 1 || r[0].Value != "b" || e != nil {
+			t.Fatalf("Query failed: got (%v, %v)", r, e)
+		}
+
+		var ids []interface{}
+		v.Each(func(i int, item jsonmap.JsonValue) bool {
+			id, _, _ := item.Key("id").Any(nil)
+			ids = append(ids, id)
+			return i < 1
+		})
+		if len(ids) != 2 {
+			t.Fatalf("Each failed: got %v", ids)
+		}
+		var keys []string
+		v.Index(0).EachKey(func(key string, member jsonmap.JsonValue) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if !reflect.DeepEqual(keys, []string{"id", "tags"}) {
+			t.Fatalf("EachKey failed: got %v", keys)
+		}
+		if m, ok := v.Index(1).Map(); !ok || len(m) != 2 {
+			t.Fatalf("Map failed: got (%v, %v)", m, ok)
+		}
+	}
+
+	v, _ := jsonmap.UnmarshalValue([]byte(`7095620078347567873`), jsonmap.SmartNumbers())
+	if i, f, e := v.Int64(0); i != 7095620078347567873 || !f || e != nil {
+		t.Fatalf("top level number failed: got (%v, %v, %v)", i, f, e)
+	}
+	if _, err := jsonmap.UnmarshalValue([]byte(`[[1]]`), jsonmap.MaxDepth(1)); !errors.Is(err, jsonmap.ErrLimitExceeded) {
+		t.Fatalf("UnmarshalValue with MaxDepth failed: got %v", err)
+	}
+
+	// interoperate with JsonMap
+	jm := jsonmap.JsonMap{"a": []interface{}{1.0, 2.0}}
+	if i, f, e := jm.Value().At("a", "-1").Int(0); i != 2 || !f || e != nil {
+		t.Fatalf("JsonMap.Value failed: got (%v, %v, %v)", i, f, e)
+	}
+	if i, f, e := jm.WithPolicy(jsonmap.ConvPolicy{Coerce: true}).Value().At("a", "0").Str(""); i != "1" || !f || e != nil {
+		t.Fatalf("View.Value failed: got (%v, %v, %v)", i, f, e)
+	}
+}
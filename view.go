@@ -0,0 +1,520 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap
+
+// View is a JsonMap bound with a conversion policy, it has the same getters as JsonMap
+// but converts values by its own policy. The zero value is an empty map with the default policy.
+type View struct {
+	getters
+}
+
+func newView(m JsonMap, p ConvPolicy) View {
+	return View{getters{policyMap{m, p}}}
+}
+
+// the underlying map
+func (v View) Map() JsonMap {
+	pm, _ := v.src.(policyMap)
+	return pm.m
+}
+
+func (v View) Policy() ConvPolicy {
+	return v.source().convPolicy()
+}
+
+// bind another conversion policy to the same map
+func (v View) WithPolicy(p ConvPolicy) View {
+	return newView(v.Map(), p)
+}
+
+// same view with coercion enabled, other rules of the policy are kept
+func (v View) Coercing() View {
+	p := v.Policy()
+	p.Coerce = true
+	return v.WithPolicy(p)
+}
+
+func (v View) String() string {
+	return v.Map().String()
+}
+
+// policyMap is the Getter of View
+type policyMap struct {
+	m      JsonMap
+	policy ConvPolicy
+}
+
+func (pm policyMap) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	return pm.m.lookup(keyPath)
+}
+
+func (pm policyMap) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	return pm.m.pointerLookup(keyPath)
+}
+
+func (pm policyMap) lookupKey(key string) (val interface{}, found bool, err error) {
+	return pm.m.lookupKey(key)
+}
+
+func (pm policyMap) convPolicy() ConvPolicy {
+	return pm.policy
+}
+
+// sub map with the same policy
+func (v View) subView(m JsonMap) View {
+	return newView(m, v.Policy())
+}
+
+func (v View) GetSubMap(key string, def JsonMap) (val View, found bool, err error) {
+	m, found, err := v.Map().GetSubMap(key, def)
+	return v.subView(m), found, err
+}
+
+func (v View) RGetSubMap(keyPath []string, def JsonMap) (val View, found bool, err error) {
+	m, found, err := v.Map().RGetSubMap(keyPath, def)
+	return v.subView(m), found, err
+}
+
+func (v View) DGetSubMap(path string, def JsonMap) (val View, found bool, err error) {
+	m, found, err := v.Map().DGetSubMap(path, def)
+	return v.subView(m), found, err
+}
+
+func (v View) PGetSubMap(pointer string, def JsonMap) (val View, found bool, err error) {
+	m, found, err := v.Map().PGetSubMap(pointer, def)
+	return v.subView(m), found, err
+}
+
+//// getters is embedded by View and other types to provide the same getters as JsonMap
+
+// getters implements getters on a Getter
+type getters struct {
+	src Getter
+}
+
+func (g getters) source() Getter {
+	if g.src == nil {
+		return JsonMap(nil)
+	}
+	return g.src
+}
+
+// getters itself is a Getter, so the types embedding it can be used by generic functions
+
+func (g getters) lookup(keyPath []string) (val interface{}, found bool, err error) {
+	return g.source().lookup(keyPath)
+}
+
+func (g getters) pointerLookup(keyPath []string) (val interface{}, found bool, err error) {
+	return g.source().pointerLookup(keyPath)
+}
+
+func (g getters) lookupKey(key string) (val interface{}, found bool, err error) {
+	return g.source().lookupKey(key)
+}
+
+func (g getters) convPolicy() ConvPolicy {
+	return g.source().convPolicy()
+}
+
+// fetch origin value, no type assurance
+func (g getters) Get(key string, def interface{}) (val interface{}, found bool, err error) {
+	if val, found, err = g.source().lookupKey(key); !found || err != nil {
+		return def, found, err
+	}
+	return val, true, nil
+}
+
+// this method ensures val’s type is same as def
+func (g getters) GetAny(key string, def interface{}) (val interface{}, found bool, err error) {
+	d := g.source()
+	raw, found, err := d.lookupKey(key)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, err = d.convPolicy().toAny(raw, def)
+	if err != nil {
+		err = withPath(err, []string{key})
+	}
+	return val, true, err
+}
+
+// fetch origin value, no type assurance
+func (g getters) RGet(keyPath []string, def interface{}) (val interface{}, found bool, err error) {
+	if len(keyPath) == 0 {
+		return def, false, emptyPathError()
+	}
+	val, found, err = g.source().lookup(keyPath)
+	if !found || err != nil {
+		return def, found, err
+	}
+	return val, true, nil
+}
+
+// this method ensures val’s type is same as def
+func (g getters) RGetAny(keyPath []string, def interface{}) (val interface{}, found bool, err error) {
+	val, found, err = g.RGet(keyPath, def)
+	if !found || err != nil {
+		return
+	}
+	val, err = g.source().convPolicy().toAny(val, def)
+	return val, true, withPath(err, keyPath)
+}
+
+// fetch origin value, no type assurance
+func (g getters) DGet(path string, def interface{}) (val interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return g.RGet(keyPath, def)
+}
+
+// this method ensures val’s type is same as def
+func (g getters) DGetAny(path string, def interface{}) (val interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return g.RGetAny(keyPath, def)
+}
+
+// fetch origin value by JSON Pointer, no type assurance
+func (g getters) PGet(pointer string, def interface{}) (val interface{}, found bool, err error) {
+	return pGet(g.source(), pointer, def)
+}
+
+// this method ensures val’s type is same as def
+func (g getters) PGetAny(pointer string, def interface{}) (val interface{}, found bool, err error) {
+	return pGetAny(g.source(), pointer, def)
+}
+
+//// directly get, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) GetString(key string, def string) (val string, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetBool(key string, def bool) (val bool, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetFloat64(key string, def float64) (val float64, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetFloat32(key string, def float32) (val float32, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetInt64(key string, def int64) (val int64, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetUint64(key string, def uint64) (val uint64, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetInt32(key string, def int32) (val int32, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetUint32(key string, def uint32) (val uint32, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetInt(key string, def int) (val int, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+func (g getters) GetUint(key string, def uint) (val uint, found bool, err error) {
+	return Get(g.source(), key, def)
+}
+
+//// recursively get, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) RGetString(keyPath []string, def string) (val string, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetBool(keyPath []string, def bool) (val bool, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetFloat64(keyPath []string, def float64) (val float64, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetFloat32(keyPath []string, def float32) (val float32, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetInt64(keyPath []string, def int64) (val int64, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUint64(keyPath []string, def uint64) (val uint64, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetInt32(keyPath []string, def int32) (val int32, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUint32(keyPath []string, def uint32) (val uint32, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetInt(keyPath []string, def int) (val int, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUint(keyPath []string, def uint) (val uint, found bool, err error) {
+	return RGet(g.source(), keyPath, def)
+}
+
+//// get by dotted path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) DGetString(path string, def string) (val string, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetBool(path string, def bool) (val bool, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetFloat64(path string, def float64) (val float64, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetFloat32(path string, def float32) (val float32, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetInt64(path string, def int64) (val int64, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetUint64(path string, def uint64) (val uint64, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetInt32(path string, def int32) (val int32, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetUint32(path string, def uint32) (val uint32, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetInt(path string, def int) (val int, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+func (g getters) DGetUint(path string, def uint) (val uint, found bool, err error) {
+	return DGet(g.source(), path, def)
+}
+
+//// directly get slice, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) GetSlice(key string, def []interface{}) (val []interface{}, found bool, err error) {
+	return g.rGetSlice([]string{key}, def)
+}
+
+func (g getters) GetAnySlice(key string, def []interface{}, itemType interface{}) (
+	val []interface{}, found bool, err error) {
+	return g.getAnySlice([]string{key}, def, itemType)
+}
+
+func (g getters) GetStringSlice(key string, def []string) (val []string, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetBoolSlice(key string, def []bool) (val []bool, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetFloat64Slice(key string, def []float64) (val []float64, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetFloat32Slice(key string, def []float32) (val []float32, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetInt64Slice(key string, def []int64) (val []int64, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetUint64Slice(key string, def []uint64) (val []uint64, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetInt32Slice(key string, def []int32) (val []int32, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetUint32Slice(key string, def []uint32) (val []uint32, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetIntSlice(key string, def []int) (val []int, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+func (g getters) GetUintSlice(key string, def []uint) (val []uint, found bool, err error) {
+	return GetSlice(g.source(), key, def)
+}
+
+//// recursively get slice, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) RGetSlice(keyPath []string, def []interface{}) (val []interface{}, found bool, err error) {
+	return g.rGetSlice(keyPath, def)
+}
+
+func (g getters) RGetAnySlice(keyPath []string, def []interface{}, itemType interface{}) (
+	val []interface{}, found bool, err error) {
+	return g.getAnySlice(keyPath, def, itemType)
+}
+
+func (g getters) RGetStringSlice(keyPath []string, def []string) (val []string, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetBoolSlice(keyPath []string, def []bool) (val []bool, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetFloat64Slice(keyPath []string, def []float64) (val []float64, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetFloat32Slice(keyPath []string, def []float32) (val []float32, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetInt64Slice(keyPath []string, def []int64) (val []int64, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUint64Slice(keyPath []string, def []uint64) (val []uint64, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetInt32Slice(keyPath []string, def []int32) (val []int32, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUint32Slice(keyPath []string, def []uint32) (val []uint32, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetIntSlice(keyPath []string, def []int) (val []int, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+func (g getters) RGetUintSlice(keyPath []string, def []uint) (val []uint, found bool, err error) {
+	return RGetSlice(g.source(), keyPath, def)
+}
+
+//// get slice by dotted path, and specialization for string/bool/float64/float32/int64/uint64/int32/uint32/int/uint
+
+func (g getters) DGetSlice(path string, def []interface{}) (val []interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return g.rGetSlice(keyPath, def)
+}
+
+func (g getters) DGetAnySlice(path string, def []interface{}, itemType interface{}) (
+	val []interface{}, found bool, err error) {
+	keyPath, err := parsePathCached(path)
+	if err != nil {
+		return def, false, err
+	}
+	return g.getAnySlice(keyPath, def, itemType)
+}
+
+func (g getters) DGetStringSlice(path string, def []string) (val []string, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetBoolSlice(path string, def []bool) (val []bool, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetFloat64Slice(path string, def []float64) (val []float64, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetFloat32Slice(path string, def []float32) (val []float32, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetInt64Slice(path string, def []int64) (val []int64, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetUint64Slice(path string, def []uint64) (val []uint64, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetInt32Slice(path string, def []int32) (val []int32, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetUint32Slice(path string, def []uint32) (val []uint32, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetIntSlice(path string, def []int) (val []int, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+func (g getters) DGetUintSlice(path string, def []uint) (val []uint, found bool, err error) {
+	return DGetSlice(g.source(), path, def)
+}
+
+// get slice by key path
+func (g getters) rGetSlice(keyPath []string, def []interface{}) (val []interface{}, found bool, err error) {
+	raw, found, err := g.RGet(keyPath, nil)
+	if !found || err != nil {
+		return def, found, err
+	}
+	val, ok := raw.([]interface{})
+	if !ok {
+		return def, true, withPath(typeError(raw, def), keyPath)
+	}
+	return val, true, nil
+}
+
+func (g getters) getAnySlice(keyPath []string, def []interface{}, itemType interface{}) (
+	val []interface{}, found bool, err error) {
+	raw, found, err := g.rGetSlice(keyPath, def)
+	if !found || err != nil {
+		return def, found, err
+	}
+	p := g.source().convPolicy()
+	val = make([]interface{}, 0, len(raw))
+	for idx, i := range raw {
+		v, e := p.toAny(i, itemType)
+		if e != nil {
+			return def, true, withIndex(e, keyPath, idx)
+		}
+		val = append(val, v)
+	}
+	return val, true, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2022 Shuangquan Li. All Rights Reserved.
+//
+// Licensed under the MIT License (the "License"); you may not use this file
+// except in compliance with the License. You may obtain a copy of the License
+// at
+//
+//   http://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package jsonmap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/peacalm/go-jsonmap"
+)
+
+func TestView(t *testing.T) {
+	data := `{"a":{"b":[1.5, -2.5, 3]}, "f":1.5, "nf":-1.5, "i":2, "s":"str"}`
+	for _, useNumber := range []bool{false, true} {
+		jm, _ := jsonmap.Unmarshal([]byte(data), useNumber)
+
+		// default policy is Truncate, same as JsonMap
+		v := jm.WithPolicy(jsonmap.ConvPolicy{})
+		if i, f, e := v.GetInt("f", 0); i != 1 || !f || e != nil {
+			t.Fatalf("Truncate GetInt by 1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.GetInt("nf", 0); i != -1 || !f || e != nil {
+			t.Fatalf("Truncate GetInt by -1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+
+		v = jm.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.RejectFraction})
+		if i, f, e := v.GetInt("f", 7); i != 7 || !f || !errors.Is(e, jsonmap.ErrFraction) {
+			t.Fatalf("RejectFraction GetInt by 1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.GetInt("i", 7); i != 2 || !f || e != nil {
+			t.Fatalf("RejectFraction GetInt by 2 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if x, f, e := v.GetFloat64("f", 7); x != 1.5 || !f || e != nil {
+			t.Fatalf("RejectFraction GetFloat64 by 1.5 failed: got (%v, %v, %v)", x, f, e)
+		}
+		var pe *jsonmap.PathError
+		if _, _, e := v.RGetIntSlice([]string{"a", "b"}, nil); !errors.Is(e, jsonmap.ErrFraction) || !errors.As(e, &pe) || pe.Index != 2 {
+			t.Fatalf("RejectFraction RGetIntSlice failed: got %v", e)
+		}
+
+		v = jm.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.Round})
+		if i, f, e := v.GetInt("f", 0); i != 2 || !f || e != nil {
+			t.Fatalf("Round GetInt by 1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := v.DGetInt("nf", 0); i != -2 || !f || e != nil {
+			t.Fatalf("Round DGetInt by -1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if s, f, e := v.DGetIntSlice("a.b", nil); len(s) != 3 || s[0] != 2 || s[1] != -3 || s[2] != 3 || !f || e != nil {
+			t.Fatalf("Round DGetIntSlice failed: got (%v, %v, %v)", s, f, e)
+		}
+		if i, f, e := jsonmap.PGet(v, "/a/b/1", 0); i != -3 || !f || e != nil {
+			t.Fatalf("Round PGet by -2.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+		if i, f, e := jsonmap.MustCompilePath("a", "b", "0").Int(v, 0); i != 2 || !f || e != nil {
+			t.Fatalf("Round Path.Int by 1.5 failed: got (%v, %v, %v)", i, f, e)
+		}
+
+		// sub map keeps the policy
+		sub, f, e := v.GetSubMap("a", nil)
+		if !f || e != nil || sub.Policy() != v.Policy() {
+			t.Fatalf("GetSubMap failed: got (%v, %v, %v)", sub, f, e)
+		}
+		if i, f, e := sub.RGetInt([]string{"b", "0"}, 0); i != 2 || !f || e != nil {
+			t.Fatalf("Round RGetInt in sub map failed: got (%v, %v, %v)", i, f, e)
+		}
+		if _, f, e := v.GetInt("s", 0); !f || !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("GetInt by string failed: got (%v, %v)", f, e)
+		}
+		if _, _, e := v.GetSlice("s", nil); !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("GetSlice by string failed: got %v", e)
+		}
+	}
+
+	// zero value is an empty view
+	var v jsonmap.View
+	if i, f, e := v.GetInt("x", 1); i != 1 || f || e != nil {
+		t.Fatalf("GetInt on zero View failed: got (%v, %v, %v)", i, f, e)
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	data := `{"port":"8080", "enabled":"true", "id":12345, "ratio":"1.5", "big":7095620078347567873,
+		"small":1e-7, "flag":false, "ports":["80", 443], "s":"str", "pad":" 1", "hex":"0x10"}`
+	for _, useNumber := range []bool{false, true} {
+		jm, _ := jsonmap.Unmarshal([]byte(data), useNumber)
+		if _, _, e := jm.GetInt("port", 0); !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("GetInt by string should fail by default: got %v", e)
+		}
+		v := jm.Coercing()
+		if p, f, e := v.GetInt("port", 0); p != 8080 || !f || e != nil {
+			t.Fatalf("GetInt by \"8080\" failed: got (%v, %v, %v)", p, f, e)
+		}
+		if b, f, e := v.GetBool("enabled", false); !b || !f || e != nil {
+			t.Fatalf("GetBool by \"true\" failed: got (%v, %v, %v)", b, f, e)
+		}
+		if s, f, e := v.GetString("id", ""); s != "12345" || !f || e != nil {
+			t.Fatalf("GetString by 12345 failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := v.GetString("small", ""); s != "1e-7" || !f || e != nil {
+			t.Fatalf("GetString by 1e-7 failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := v.GetString("flag", ""); s != "false" || !f || e != nil {
+			t.Fatalf("GetString by false failed: got (%v, %v, %v)", s, f, e)
+		}
+		if x, f, e := v.GetFloat64("ratio", 0); x != 1.5 || !f || e != nil {
+			t.Fatalf("GetFloat64 by \"1.5\" failed: got (%v, %v, %v)", x, f, e)
+		}
+		if i, f, e := v.GetInt("ratio", 0); i != 1 || !f || e != nil {
+			t.Fatalf("GetInt by \"1.5\" failed: got (%v, %v, %v)", i, f, e)
+		}
+		if s, f, e := v.GetIntSlice("ports", nil); len(s) != 2 || s[0] != 80 || s[1] != 443 || !f || e != nil {
+			t.Fatalf("GetIntSlice failed: got (%v, %v, %v)", s, f, e)
+		}
+		if s, f, e := v.GetStringSlice("ports", nil); len(s) != 2 || s[0] != "80" || s[1] != "443" || !f || e != nil {
+			t.Fatalf("GetStringSlice failed: got (%v, %v, %v)", s, f, e)
+		}
+		for _, key := range []string{"s", "pad", "hex"} {
+			if i, f, e := v.GetInt(key, 7); i != 7 || !f || !errors.Is(e, jsonmap.ErrTypeMismatch) {
+				t.Fatalf("GetInt by %s failed: got (%v, %v, %v)", key, i, f, e)
+			}
+		}
+		if _, _, e := v.GetBool("id", false); !errors.Is(e, jsonmap.ErrTypeMismatch) {
+			t.Fatalf("GetBool by number should fail: got %v", e)
+		}
+		var pe *jsonmap.PathError
+		r := v.WithPolicy(jsonmap.ConvPolicy{Fraction: jsonmap.RejectFraction}).Coercing()
+		if _, _, e := r.GetInt("ratio", 0); !errors.Is(e, jsonmap.ErrFraction) || !errors.As(e, &pe) || pe.Actual != "string" {
+			t.Fatalf("GetInt by \"1.5\" with RejectFraction failed: got %v", e)
+		}
+	}
+
+	// json.Number keeps its original text
+	jm, _ := jsonmap.Unmarshal([]byte(data), true)
+	if s, f, e := jm.Coercing().GetString("big", ""); s != "7095620078347567873" || !f || e != nil {
+		t.Fatalf("GetString by big number failed: got (%v, %v, %v)", s, f, e)
+	}
+	if i, f, e := jm.Coercing().GetInt64("big", 0); i != 7095620078347567873 || !f || e != nil {
+		t.Fatalf("GetInt64 by big number failed: got (%v, %v, %v)", i, f, e)
+	}
+}